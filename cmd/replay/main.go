@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/service/exchange.go"
+)
+
+// replay rebuilds the order books from a command journal and prints every match,
+// it is how a production incident is reproduced locally
+func main() {
+	path := flag.String("journal", "data/commands.journal", "path to the command journal")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	file, err := os.Open(*path)
+	if err != nil {
+		logger.Error("Failed to open journal", "error", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := exchange.Replay(file, out, logger); err != nil {
+		logger.Error("Failed to replay journal", "error", err)
+		os.Exit(1)
+	}
+
+	if err := out.Flush(); err != nil {
+		logger.Error("Failed to write matches", "error", err)
+		os.Exit(1)
+	}
+}
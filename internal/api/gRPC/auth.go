@@ -0,0 +1,93 @@
+package gRPC
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The service is reached through the API gateway only, which authenticates
+// the caller and forwards the identity in request metadata
+const (
+	userIDMetadataKey = "x-user-id"
+	roleMetadataKey   = "x-user-role"
+)
+
+const (
+	roleUser        = "user"
+	roleMarketMaker = "market_maker"
+	roleAuditor     = "auditor"
+	roleAdmin       = "admin"
+)
+
+type caller struct {
+	userID int64
+	role   string
+}
+
+func callerFromContext(ctx context.Context) (caller, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return caller{}, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	userIDs := md.Get(userIDMetadataKey)
+	if len(userIDs) == 0 {
+		return caller{}, status.Errorf(codes.Unauthenticated, "missing %s", userIDMetadataKey)
+	}
+
+	userID, err := strconv.ParseInt(userIDs[0], 10, 64)
+	if err != nil {
+		return caller{}, status.Errorf(codes.Unauthenticated, "invalid %s", userIDMetadataKey)
+	}
+
+	var role = roleUser
+	if roles := md.Get(roleMetadataKey); len(roles) > 0 {
+		role = roles[0]
+	}
+
+	return caller{userID: userID, role: role}, nil
+}
+
+// requireReader lets users through to their own data and admins and auditors to anyone's
+func requireReader(ctx context.Context, userID int64) (caller, error) {
+	c, err := callerFromContext(ctx)
+	if err != nil {
+		return caller{}, err
+	}
+
+	if c.userID != userID && c.role != roleAdmin && c.role != roleAuditor {
+		return caller{}, status.Errorf(codes.PermissionDenied, "data of another user is not allowed")
+	}
+	return c, nil
+}
+
+// requireOwner lets users act on their own orders and admins on anyone's,
+// auditors only read
+func requireOwner(ctx context.Context, userID int64) (caller, error) {
+	c, err := callerFromContext(ctx)
+	if err != nil {
+		return caller{}, err
+	}
+
+	if c.userID != userID && c.role != roleAdmin {
+		return caller{}, status.Errorf(codes.PermissionDenied, "orders of another user are not allowed")
+	}
+	return c, nil
+}
+
+func requireRole(ctx context.Context, roles ...string) (caller, error) {
+	c, err := callerFromContext(ctx)
+	if err != nil {
+		return caller{}, err
+	}
+
+	if !slices.Contains(roles, c.role) {
+		return caller{}, status.Errorf(codes.PermissionDenied, "role %q is not allowed", c.role)
+	}
+	return c, nil
+}
@@ -0,0 +1,41 @@
+package gRPC
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// callerContext is the context of a request the gateway forwarded for the user
+func callerContext(userID, role string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(userIDMetadataKey, userID, roleMetadataKey, role))
+}
+
+func TestRequireReaderAndOwner(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		ctx    context.Context
+		userID int64
+		read   codes.Code
+		write  codes.Code
+	}{
+		{"own data", callerContext("1", roleUser), 1, codes.OK, codes.OK},
+		{"another user", callerContext("2", roleUser), 1, codes.PermissionDenied, codes.PermissionDenied},
+		{"market maker for another user", callerContext("2", roleMarketMaker), 1, codes.PermissionDenied, codes.PermissionDenied},
+		{"auditor only reads", callerContext("9", roleAuditor), 1, codes.OK, codes.PermissionDenied},
+		{"admin", callerContext("9", roleAdmin), 1, codes.OK, codes.OK},
+		{"anonymous", context.Background(), 1, codes.Unauthenticated, codes.Unauthenticated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := requireReader(tc.ctx, tc.userID)
+			require.Equal(t, tc.read, status.Code(err))
+
+			_, err = requireOwner(tc.ctx, tc.userID)
+			require.Equal(t, tc.write, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package gRPC
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func orderToPb(o models.Order) *pb.Order {
+	return &pb.Order{
+		ID:            o.ID,
+		UserID:        o.UserID,
+		IsBid:         o.IsBid,
+		Symbol:        o.Symbol,
+		Price:         o.Price,
+		Qty:           o.Qty,
+		SizeFilled:    o.SizeFilled,
+		Status:        o.Status,
+		Type:          o.Type,
+		CreatedAt:     timestamppb.New(o.CreatedAt),
+		ClosedAt:      timestamppb.New(o.ClosedAt.Time),
+		ClientOrderID: o.ClientOrderID,
+	}
+}
+
+func ordersToPb(orders []models.Order) *pb.Orders {
+	var res pb.Orders
+	for _, o := range orders {
+		res.Orders = append(res.Orders, orderToPb(o))
+	}
+	return &res
+}
+
+func orderBookSnapshotToPb(snapshot models.OrderBookSnapshot) *pb.OrderBookSnapshot {
+	return &pb.OrderBookSnapshot{
+		Symbol:   snapshot.Symbol,
+		Sequence: snapshot.Sequence,
+		Bids:     priceLevelsToPb(snapshot.Bids),
+		Asks:     priceLevelsToPb(snapshot.Asks),
+	}
+}
+
+func priceLevelsToPb(levels []models.PriceLevel) []*pb.PriceLevel {
+	var res []*pb.PriceLevel
+	for _, l := range levels {
+		res = append(res, &pb.PriceLevel{
+			Price:      l.Price,
+			Size:       l.Size,
+			OrderCount: int32(l.OrderCount),
+		})
+	}
+	return res
+}
+
+func restingOrderToPb(o models.RestingOrder) *pb.RestingOrder {
+	return &pb.RestingOrder{
+		ID:            o.ID,
+		UserID:        o.UserID,
+		IsBid:         o.IsBid,
+		Price:         o.Price,
+		Qty:           o.Qty,
+		QueuePosition: int32(o.QueuePosition),
+		CreatedAt:     timestamppb.New(o.CreatedAt),
+	}
+}
+
+func priceLevelUpdateToPb(u models.PriceLevelUpdate) *pb.PriceLevelUpdate {
+	return &pb.PriceLevelUpdate{
+		Symbol:     u.Symbol,
+		Sequence:   u.Sequence,
+		IsBid:      u.IsBid,
+		Price:      u.Price,
+		Size:       u.Size,
+		OrderCount: int32(u.OrderCount),
+	}
+}
+
+func tradeToPb(t models.Trade) *pb.Trade {
+	return &pb.Trade{
+		ID:        t.ID,
+		Symbol:    t.Symbol,
+		Price:     t.Price,
+		Qty:       t.Qty,
+		IsBid:     t.IsBid,
+		Timestamp: timestamppb.New(t.ExecutedAt),
+	}
+}
+
+func fillToPb(f models.Fill) *pb.Fill {
+	return &pb.Fill{
+		TradeID:        f.TradeID,
+		OrderID:        f.OrderID,
+		UserID:         f.UserID,
+		CounterOrderID: f.CounterOrderID,
+		Symbol:         f.Symbol,
+		IsBid:          f.IsBid,
+		Price:          f.Price,
+		Qty:            f.Qty,
+		Liquidity:      f.Liquidity,
+		Fee:            f.Fee,
+		FeeCurrency:    f.FeeCurrency,
+		Timestamp:      timestamppb.New(f.ExecutedAt),
+	}
+}
+
+func fillsToPb(fills []models.Fill) *pb.Fills {
+	var res pb.Fills
+	for _, f := range fills {
+		res.Fills = append(res.Fills, fillToPb(f))
+	}
+	return &res
+}
+
+func executionReportToPb(r models.ExecutionReport) *pb.ExecutionReport {
+	return &pb.ExecutionReport{
+		ExecType:       r.ExecType,
+		Order:          orderToPb(r.Order),
+		TradeID:        r.TradeID,
+		LastQty:        r.LastQty,
+		LastPrice:      r.LastPrice,
+		CounterOrderID: r.CounterOrderID,
+		Liquidity:      r.Liquidity,
+		Fee:            r.Fee,
+		FeeCurrency:    r.FeeCurrency,
+		Reason:         r.Reason,
+		Timestamp:      timestamppb.New(r.CreatedAt),
+	}
+}
+
+func candleToPb(c models.Candle) *pb.Candle {
+	return &pb.Candle{
+		Symbol:      c.Symbol,
+		Interval:    c.Interval,
+		OpenTime:    timestamppb.New(c.OpenTime),
+		CloseTime:   timestamppb.New(c.CloseTime),
+		Open:        c.Open,
+		High:        c.High,
+		Low:         c.Low,
+		Close:       c.Close,
+		Volume:      c.Volume,
+		QuoteVolume: c.QuoteVolume,
+		TradeCount:  int32(c.TradeCount),
+		Closed:      c.Closed,
+	}
+}
+
+func tickerToPb(t models.Ticker) *pb.Ticker {
+	return &pb.Ticker{
+		Symbol:             t.Symbol,
+		LastPrice:          t.LastPrice,
+		BestBid:            t.BestBid,
+		BestBidSize:        t.BestBidSize,
+		BestAsk:            t.BestAsk,
+		BestAskSize:        t.BestAskSize,
+		Open:               t.Open,
+		High:               t.High,
+		Low:                t.Low,
+		Volume:             t.Volume,
+		QuoteVolume:        t.QuoteVolume,
+		PriceChange:        t.PriceChange,
+		PriceChangePercent: t.PriceChangePercent,
+		VWAP:               t.VWAP,
+		TradeCount:         int32(t.TradeCount),
+	}
+}
+
+func orderBookToPb(o models.OrderBook) *pb.OrderBook {
+	var orderBook = pb.OrderBook{
+		Symbol:    o.Symbol,
+		Status:    o.Status,
+		TickSize:  o.TickSize,
+		LotSize:   o.LotSize,
+		MinQty:    o.MinQty,
+		CreatedAt: timestamppb.New(o.CreatedAt),
+	}
+	if o.DeletedAt.Valid {
+		orderBook.DeletedAt = timestamppb.New(o.DeletedAt.Time)
+	}
+	if o.DelistAt.Valid {
+		orderBook.DelistAt = timestamppb.New(o.DelistAt.Time)
+	}
+	return &orderBook
+}
+
+func feeScheduleToPb(s models.FeeSchedule) *pb.FeeSchedule {
+	var schedule = pb.FeeSchedule{Symbol: s.Symbol}
+	for _, tier := range s.Tiers {
+		schedule.Tiers = append(schedule.Tiers, &pb.FeeTier{
+			MinVolume: tier.MinVolume,
+			MakerRate: tier.MakerRate,
+			TakerRate: tier.TakerRate,
+		})
+	}
+	return &schedule
+}
+
+func userLimitsFromPb(l *pb.UserLimits) models.UserLimits {
+	return models.UserLimits{
+		UserID:             l.UserID,
+		Symbol:             l.Symbol,
+		MaxOpenOrders:      int(l.MaxOpenOrders),
+		MaxRestingNotional: l.MaxRestingNotional,
+		MaxOrderQty:        l.MaxOrderQty,
+	}
+}
+
+func userLimitsToPb(l models.UserLimits) *pb.UserLimits {
+	return &pb.UserLimits{
+		UserID:             l.UserID,
+		Symbol:             l.Symbol,
+		MaxOpenOrders:      int32(l.MaxOpenOrders),
+		MaxRestingNotional: l.MaxRestingNotional,
+		MaxOrderQty:        l.MaxOrderQty,
+	}
+}
+
+// encodeOrderCursor turns the cursor into an opaque page token, empty for no cursor
+func encodeOrderCursor(c *models.OrderCursor) string {
+	if c == nil {
+		return ""
+	}
+	token := c.CreatedAt.Format(time.RFC3339Nano) + "," + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func decodeOrderCursor(token string) (*models.OrderCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("Invalid page token")
+	}
+
+	createdAt, id, ok := strings.Cut(string(decoded), ",")
+	if !ok {
+		return nil, errors.New("Invalid page token")
+	}
+
+	var cursor models.OrderCursor
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, errors.New("Invalid page token")
+	}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, errors.New("Invalid page token")
+	}
+	return &cursor, nil
+}
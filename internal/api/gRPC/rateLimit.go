@@ -0,0 +1,223 @@
+package gRPC
+
+import (
+	"context"
+	"math"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// retryAfterMetadataKey carries the seconds to wait before the next request of a limited class
+const retryAfterMetadataKey = "retry-after"
+
+// bucketSweepInterval is how often buckets refilled to their burst are dropped
+const bucketSweepInterval = time.Minute
+
+const (
+	classOrders  = "orders"
+	classCancels = "cancels"
+	classQueries = "queries"
+)
+
+// methodClasses maps the methods with their own budget, every other method is a query
+var methodClasses = map[string]string{
+	"PlaceOrder":                 classOrders,
+	"CancelOrder":                classCancels,
+	"CancelOrderByClientOrderID": classCancels,
+	"CancelOrders":               classCancels,
+}
+
+func methodClass(fullMethod string) string {
+	if class, ok := methodClasses[path.Base(fullMethod)]; ok {
+		return class
+	}
+	return classQueries
+}
+
+// rateClient is who a request is counted against: the authenticated caller,
+// else the peer address shared by every anonymous request coming from it
+type rateClient struct {
+	userID int64
+	addr   string
+}
+
+type bucketKey struct {
+	rateClient
+	class string
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// rateLimiter keeps a token bucket per client and method class
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]config.RateLimit
+	buckets map[bucketKey]*bucket
+	sweptAt time.Time
+	now     func() time.Time
+}
+
+func newRateLimiter(cfg config.Config) *rateLimiter {
+	return &rateLimiter{
+		limits: map[string]config.RateLimit{
+			classOrders:  cfg.OrderRateLimit,
+			classCancels: cfg.CancelRateLimit,
+			classQueries: cfg.QueryRateLimit,
+		},
+		buckets: make(map[bucketKey]*bucket),
+		now:     time.Now,
+	}
+}
+
+// refill returns the bucket of key topped up to now, caller holds l.mu
+func (l *rateLimiter) refill(key bucketKey, now time.Time) *bucket {
+	limit := l.limits[key.class]
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*float64(limit.PerSecond))
+	b.updatedAt = now
+	return b
+}
+
+// take spends a token of the client's budget for class, when none is left it
+// returns how long until the next one
+func (l *rateLimiter) take(client rateClient, class string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.sweptAt) > bucketSweepInterval {
+		l.sweep(now)
+	}
+
+	b := l.refill(bucketKey{rateClient: client, class: class}, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	perSecond := float64(l.limits[class].PerSecond)
+	return time.Duration((1 - b.tokens) / perSecond * float64(time.Second)), false
+}
+
+// sweep drops the buckets that are full again, caller holds l.mu
+func (l *rateLimiter) sweep(now time.Time) {
+	for key := range l.buckets {
+		if b := l.refill(key, now); b.tokens >= float64(l.limits[key.class].Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweptAt = now
+}
+
+// usage returns the budget left to the user in every class
+func (l *rateLimiter) usage(userID int64) []*pb.RateLimitUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		now   = l.now()
+		usage []*pb.RateLimitUsage
+	)
+	for _, class := range []string{classOrders, classCancels, classQueries} {
+		var (
+			limit = l.limits[class]
+			b     = l.refill(bucketKey{rateClient: rateClient{userID: userID}, class: class}, now)
+		)
+		usage = append(usage, &pb.RateLimitUsage{
+			Class:     class,
+			PerSecond: int32(limit.PerSecond),
+			Burst:     int32(limit.Burst),
+			Remaining: int32(b.tokens),
+		})
+	}
+	return usage
+}
+
+// requestClient is the caller forwarded by the gateway, a request without one
+// is counted against the host it comes from. The user a request is about is
+// never used, it would let anyone spend another user's budget
+func requestClient(ctx context.Context) rateClient {
+	if c, err := callerFromContext(ctx); err == nil {
+		return rateClient{userID: c.userID}
+	}
+
+	var client rateClient
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.addr = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.addr); err == nil {
+			client.addr = host
+		}
+	}
+	return client
+}
+
+// limit fails with ResourceExhausted when the client ran out of budget for the method,
+// the wait is set in the retry-after trailer and in a RetryInfo detail
+func (l *rateLimiter) limit(client rateClient, fullMethod string, setTrailer func(metadata.MD)) error {
+	var class = methodClass(fullMethod)
+
+	wait, ok := l.take(client, class)
+	if ok {
+		return nil
+	}
+
+	setTrailer(metadata.Pairs(retryAfterMetadataKey, strconv.FormatFloat(wait.Seconds(), 'f', 3, 64)))
+
+	st, err := status.New(codes.ResourceExhausted, "rate limit of "+class+" exceeded").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(wait),
+	})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit of "+class+" exceeded")
+	}
+	return st.Err()
+}
+
+func (l *rateLimiter) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	setTrailer := func(md metadata.MD) { grpc.SetTrailer(ctx, md) }
+	if err := l.limit(requestClient(ctx), info.FullMethod, setTrailer); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor counts opening a stream as one request, messages on it are not limited
+func (l *rateLimiter) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.limit(requestClient(stream.Context()), info.FullMethod, stream.SetTrailer); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func (s *Server) GetRateLimitUsage(ctx context.Context, req *pb.UserID) (*pb.RateLimitUsages, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetRateLimitUsage request", "usage_user_id", req.UserID, "user_id", c.userID)
+
+	return &pb.RateLimitUsages{Usages: s.rateLimiter.usage(req.UserID)}, nil
+}
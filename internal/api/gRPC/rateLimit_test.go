@@ -0,0 +1,80 @@
+package gRPC
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var (
+		now     = time.Now()
+		limiter = newRateLimiter(config.Config{
+			OrderRateLimit:  config.RateLimit{PerSecond: 2, Burst: 2},
+			CancelRateLimit: config.RateLimit{PerSecond: 10, Burst: 10},
+			QueryRateLimit:  config.RateLimit{PerSecond: 1, Burst: 1},
+		})
+		user    = rateClient{userID: 1}
+		trailer metadata.MD
+	)
+	limiter.now = func() time.Time { return now }
+	setTrailer := func(md metadata.MD) { trailer = md }
+
+	require.NoError(t, limiter.limit(user, "/pb.matchingEngine/PlaceOrder", setTrailer))
+	require.NoError(t, limiter.limit(user, "/pb.matchingEngine/PlaceOrder", setTrailer))
+	require.Nil(t, trailer)
+
+	// the budget is spent, the next token comes in half a second
+	err := limiter.limit(user, "/pb.matchingEngine/PlaceOrder", setTrailer)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Equal(t, []string{"0.500"}, trailer.Get(retryAfterMetadataKey))
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.Equal(t, 500*time.Millisecond, retryInfo.RetryDelay.AsDuration())
+
+	// cancels, queries and other users have budgets of their own
+	require.NoError(t, limiter.limit(user, "/pb.matchingEngine/CancelOrder", setTrailer))
+	require.NoError(t, limiter.limit(user, "/pb.matchingEngine/GetOrders", setTrailer))
+	require.NoError(t, limiter.limit(rateClient{userID: 2}, "/pb.matchingEngine/PlaceOrder", setTrailer))
+
+	now = now.Add(500 * time.Millisecond)
+	_, ok = limiter.take(user, classOrders)
+	require.True(t, ok)
+
+	usage := limiter.usage(1)
+	require.Len(t, usage, 3)
+	require.Equal(t, classQueries, usage[2].Class)
+	require.Equal(t, int32(0), usage[2].Remaining)
+}
+
+func TestRequestClient(t *testing.T) {
+	var (
+		fromPeer = func(ctx context.Context, port int) context.Context {
+			return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: port}})
+		}
+		withUserID = func(userID string) context.Context {
+			return metadata.NewIncomingContext(context.Background(), metadata.Pairs(userIDMetadataKey, userID))
+		}
+	)
+
+	// the authenticated caller is counted whatever peer it comes through
+	require.Equal(t, rateClient{userID: 1}, requestClient(fromPeer(withUserID("1"), 5000)))
+
+	// anonymous requests of a host share a budget across connections, an
+	// identity that does not parse is anonymous
+	require.Equal(t, rateClient{addr: "10.0.0.7"}, requestClient(fromPeer(context.Background(), 5000)))
+	require.Equal(t, rateClient{addr: "10.0.0.7"}, requestClient(fromPeer(withUserID("not a number"), 5001)))
+	require.Equal(t, rateClient{}, requestClient(context.Background()))
+}
@@ -7,27 +7,61 @@ import (
 	"net"
 
 	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 	"github.com/BazaarTrade/OrderMatchingService/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+const (
+	maxOrderBookDepth = 1000
+	l3ChunkSize       = 500
+)
+
+// errorDomain is the domain of the reason codes in the ErrorInfo of rejections
+const errorDomain = "orderMatching"
+
+// limitReasons are the reason codes of orders rejected by the user limits
+var limitReasons = map[string]string{
+	"Max order size exceeded":       "MAX_ORDER_SIZE",
+	"Max open orders exceeded":      "MAX_OPEN_ORDERS",
+	"Max resting notional exceeded": "MAX_RESTING_NOTIONAL",
+}
+
+// statusWithReason returns a status carrying reason in an ErrorInfo detail
+func statusWithReason(code codes.Code, msg, reason string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
 type Server struct {
-	service service.Exchanger
-	logger  *slog.Logger
+	service     service.Exchanger
+	cfg         config.Config
+	sessions    *sessions
+	rateLimiter *rateLimiter
+	logger      *slog.Logger
 
 	pb.UnimplementedMatchingEngineServer
 }
 
-func NewServer(service service.Exchanger, logger *slog.Logger) *Server {
+func NewServer(service service.Exchanger, cfg config.Config, logger *slog.Logger) *Server {
 	return &Server{
-		service: service,
-		logger:  logger,
+		service:     service,
+		cfg:         cfg,
+		sessions:    newSessions(),
+		rateLimiter: newRateLimiter(cfg),
+		logger:      logger,
 	}
 }
 
@@ -38,7 +72,10 @@ func (s *Server) StartGRPCServer() error {
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(s.rateLimiter.unaryInterceptor),
+		grpc.StreamInterceptor(s.rateLimiter.streamInterceptor),
+	)
 
 	pb.RegisterMatchingEngineServer(grpcServer, s)
 	reflection.Register(grpcServer)
@@ -53,143 +90,599 @@ func (s *Server) StartGRPCServer() error {
 }
 
 func (s *Server) PlaceOrder(ctx context.Context, req *pb.PlaceOrderReq) (*pb.Orders, error) {
-	s.logger.Info("PlaceOrder request", "user_id", req.UserID)
+	c, err := requireOwner(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("PlaceOrder request", "order_user_id", req.UserID, "user_id", c.userID)
 
 	var placeOrder = models.PlaceOrderReq{
-		UserID: req.UserID,
-		IsBid:  req.IsBid,
-		Symbol: req.Symbol,
-		Price:  req.Price,
-		Qty:    req.Qty,
-		Type:   req.Type,
+		UserID:        req.UserID,
+		IsBid:         req.IsBid,
+		Symbol:        req.Symbol,
+		Price:         req.Price,
+		Qty:           req.Qty,
+		Type:          req.Type,
+		ClientOrderID: req.ClientOrderID,
 	}
 
 	modifiedOrders, err := s.service.PlaceOrder(placeOrder)
 	if err != nil {
-		if err.Error() == "Order book not found" {
+		switch err.Error() {
+		case "Exchange is recovering":
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		case "Order book not found":
 			return nil, status.Errorf(codes.NotFound, err.Error())
+		case "Order book is not active", "Insufficient funds":
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		case "Invalid price", "Invalid qty", "Invalid order type", "Invalid client order ID":
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		case "Client order ID already used":
+			return nil, status.Errorf(codes.AlreadyExists, err.Error())
+		case "Max order size exceeded", "Max open orders exceeded", "Max resting notional exceeded":
+			return nil, statusWithReason(codes.ResourceExhausted, err.Error(), limitReasons[err.Error()])
 		}
 		return nil, status.Errorf(codes.Internal, "Failed to palce order: %v", err)
 	}
 
-	var res pb.Orders
-	for _, o := range modifiedOrders {
-		var order = pb.Order{
-			ID:         o.ID,
-			UserID:     o.UserID,
-			IsBid:      o.IsBid,
-			Symbol:     o.Symbol,
-			Price:      o.Price,
-			Qty:        o.Qty,
-			SizeFilled: o.SizeFilled,
-			Status:     o.Status,
-			Type:       o.Type,
-			CreatedAt:  timestamppb.New(o.CreatedAt),
-			ClosedAt:   timestamppb.New(o.ClosedAt.Time),
-		}
+	return ordersToPb(modifiedOrders), nil
+}
 
-		res.Orders = append(res.Orders, &order)
+func (s *Server) CancelOrder(ctx context.Context, req *pb.OrderID) (*pb.Order, error) {
+	_, c, err := s.ownOrder(ctx, req.OrderID, requireOwner)
+	if err != nil {
+		return nil, err
 	}
-	return &res, nil
+
+	s.logger.Info("CancelOrder request", "order_id", req.OrderID, "user_id", c.userID)
+
+	order, err := s.service.CancelOrder(req.OrderID)
+	if err != nil {
+		if err.Error() == "Exchange is recovering" {
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		}
+		if err.Error() == "Order book not found" || err.Error() == "Order not found" {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to cancel order: %v", err)
+	}
+	return orderToPb(order), nil
 }
 
-func (s *Server) CancelOrder(ctx context.Context, req *pb.OrderID) (*pb.Order, error) {
-	s.logger.Info("CancelOrder request", "order_id", req.OrderID)
+func (s *Server) CancelOrderByClientOrderID(ctx context.Context, req *pb.ClientOrderIDReq) (*pb.Order, error) {
+	if req.UserID == 0 || req.ClientOrderID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "userID and clientOrderID are required")
+	}
 
-	o, err := s.service.CancelOrder(req.OrderID)
+	c, err := requireOwner(ctx, req.UserID)
 	if err != nil {
-		if err.Error() == "Order book not found" {
+		return nil, err
+	}
+
+	s.logger.Info("CancelOrderByClientOrderID request", "order_user_id", req.UserID, "client_order_id", req.ClientOrderID, "user_id", c.userID)
+
+	order, err := s.service.CancelOrderByClientOrderID(req.UserID, req.ClientOrderID)
+	if err != nil {
+		switch err.Error() {
+		case "Exchange is recovering":
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		case "Order not found", "Order book not found":
 			return nil, status.Errorf(codes.NotFound, err.Error())
 		}
 		return nil, status.Errorf(codes.Internal, "Failed to cancel order: %v", err)
 	}
+	return orderToPb(order), nil
+}
 
-	var order = pb.Order{
-		ID:         o.ID,
-		UserID:     o.UserID,
-		IsBid:      o.IsBid,
-		Symbol:     o.Symbol,
-		Price:      o.Price,
-		Qty:        o.Qty,
-		SizeFilled: o.SizeFilled,
-		Status:     o.Status,
-		Type:       o.Type,
-		CreatedAt:  timestamppb.New(o.CreatedAt),
-		ClosedAt:   timestamppb.New(o.ClosedAt.Time),
+func (s *Server) CancelOrders(ctx context.Context, req *pb.CancelOrdersReq) (*pb.Orders, error) {
+	if req.UserID == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "userID is required")
 	}
-	return &order, nil
+
+	c, err := requireOwner(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("CancelOrders request", "orders_user_id", req.UserID, "symbol", req.Symbol, "user_id", c.userID)
+
+	orders, err := s.service.CancelOrders(models.OpenOrdersFilter{
+		UserID: req.UserID,
+		Symbol: req.Symbol,
+		IsBid:  req.IsBid,
+	})
+	if err != nil {
+		if err.Error() == "Exchange is recovering" {
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to cancel orders: %v", err)
+	}
+	return ordersToPb(orders), nil
 }
 
 func (s *Server) GetCurrentOrders(ctx context.Context, req *pb.UserID) (*pb.Orders, error) {
-	s.logger.Info("GetCurrentOrders request", "user_id", req.UserID)
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetCurrentOrders request", "orders_user_id", req.UserID, "user_id", c.userID)
 
 	orders, err := s.service.GetCurrentOrders(req.UserID)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to get current orders: %v", err)
 	}
+	return ordersToPb(orders), nil
+}
+
+func (s *Server) GetOrders(ctx context.Context, req *pb.OrdersReq) (*pb.OrdersPage, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetOrders request", "orders_user_id", req.UserID, "symbol", req.Symbol, "page_size", req.PageSize, "user_id", c.userID)
+
+	after, err := decodeOrderCursor(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	var filter = models.OrdersFilter{
+		UserID:   req.UserID,
+		Symbol:   req.Symbol,
+		IsBid:    req.IsBid,
+		Type:     req.Type,
+		Statuses: req.Statuses,
+		After:    after,
+		Limit:    int(req.PageSize),
+	}
+	if req.CreatedFrom != nil {
+		filter.CreatedFrom = req.CreatedFrom.AsTime()
+	}
+	if req.CreatedTo != nil {
+		filter.CreatedTo = req.CreatedTo.AsTime()
+	}
+
+	orders, next, err := s.service.GetOrders(filter)
+	if err != nil {
+		switch err.Error() {
+		case "Invalid order type", "Invalid order status", "Invalid time range":
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get orders: %v", err)
+	}
+
+	return &pb.OrdersPage{
+		Orders:        ordersToPb(orders).Orders,
+		NextPageToken: encodeOrderCursor(next),
+	}, nil
+}
+
+func (s *Server) GetOrderByClientOrderID(ctx context.Context, req *pb.ClientOrderIDReq) (*pb.Order, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetOrderByClientOrderID request", "order_user_id", req.UserID, "client_order_id", req.ClientOrderID, "user_id", c.userID)
+
+	order, err := s.service.GetOrderByClientOrderID(req.UserID, req.ClientOrderID)
+	if err != nil {
+		if err.Error() == "Order not found" {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get order: %v", err)
+	}
+	return orderToPb(order), nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *pb.OrderID) (*pb.Order, error) {
+	order, c, err := s.ownOrder(ctx, req.OrderID, requireReader)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetOrder request", "order_id", req.OrderID, "user_id", c.userID)
+
+	return orderToPb(order), nil
+}
+
+func (s *Server) GetOrderTrades(ctx context.Context, req *pb.OrderID) (*pb.Fills, error) {
+	_, c, err := s.ownOrder(ctx, req.OrderID, requireReader)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetOrderTrades request", "order_id", req.OrderID, "user_id", c.userID)
+
+	fills, err := s.service.GetOrderFills(req.OrderID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get order trades: %v", err)
+	}
+	return fillsToPb(fills), nil
+}
+
+// ownOrder returns the order when require lets the caller through to its user,
+// see requireReader and requireOwner
+func (s *Server) ownOrder(ctx context.Context, orderID int64, require func(context.Context, int64) (caller, error)) (models.Order, caller, error) {
+	if _, err := callerFromContext(ctx); err != nil {
+		return models.Order{}, caller{}, err
+	}
+
+	order, err := s.service.GetOrder(orderID)
+	if err != nil {
+		if err.Error() == "Order not found" {
+			return models.Order{}, caller{}, status.Errorf(codes.NotFound, err.Error())
+		}
+		return models.Order{}, caller{}, status.Errorf(codes.Internal, "Failed to get order: %v", err)
+	}
+
+	c, err := require(ctx, order.UserID)
+	if err != nil {
+		return models.Order{}, caller{}, err
+	}
+	return order, c, nil
+}
+
+func (s *Server) GetUserTrades(ctx context.Context, req *pb.UserTradesReq) (*pb.Fills, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetUserTrades request", "trades_user_id", req.UserID, "symbol", req.Symbol, "user_id", c.userID)
+
+	var filter = models.FillsFilter{
+		UserID: req.UserID,
+		Symbol: req.Symbol,
+		Limit:  int(req.Limit),
+	}
+	if req.From != nil {
+		filter.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		filter.To = req.To.AsTime()
+	}
+
+	fills, err := s.service.GetUserFills(filter)
+	if err != nil {
+		if err.Error() == "Invalid time range" {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get user trades: %v", err)
+	}
+	return fillsToPb(fills), nil
+}
+
+func (s *Server) CreateOrderBook(ctx context.Context, req *pb.CreateOrderBookReq) (*emptypb.Empty, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("CreateOrderBook request", "symbol", req.Symbol, "user_id", c.userID)
+
+	err = s.service.AddOrderBook(models.OrderBook{
+		Symbol:   req.Symbol,
+		TickSize: req.TickSize,
+		LotSize:  req.LotSize,
+		MinQty:   req.MinQty,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "Exchange is recovering":
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		case "Order book already exists":
+			return nil, status.Errorf(codes.AlreadyExists, err.Error())
+		case "Invalid symbol", "Invalid tick size", "Invalid lot size", "Invalid min qty":
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to create orderbook: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) DeleteOrderBook(ctx context.Context, req *pb.DeleteOrderBookReq) (*pb.Orders, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
 
-	var res pb.Orders
-	for _, o := range orders {
-		res.Orders = append(res.Orders, &pb.Order{
-			ID:         o.ID,
-			UserID:     o.UserID,
-			IsBid:      o.IsBid,
-			Symbol:     o.Symbol,
-			Price:      o.Price,
-			Qty:        o.Qty,
-			SizeFilled: o.SizeFilled,
-			Status:     o.Status,
-			Type:       o.Type,
-			CreatedAt:  timestamppb.New(o.CreatedAt),
-			ClosedAt:   timestamppb.New(o.ClosedAt.Time),
+	s.logger.Info("DeleteOrderBook request", "symbol", req.Symbol, "cancel_orders", req.CancelOrders, "user_id", c.userID)
+
+	var deleteOrderBook = models.DeleteOrderBookReq{
+		Symbol:       req.Symbol,
+		CancelOrders: req.CancelOrders,
+		Reason:       req.Reason,
+	}
+	if req.ScheduledAt != nil {
+		deleteOrderBook.ScheduledAt = req.ScheduledAt.AsTime()
+	}
+
+	orders, err := s.service.DeleteOrderBook(deleteOrderBook)
+	if err != nil {
+		switch err.Error() {
+		case "Exchange is recovering":
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		case "Order book not found":
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		case "Order book has resting orders":
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to delete orderbook: %v", err)
+	}
+	return ordersToPb(orders), nil
+}
+
+func (s *Server) ListOrderBooks(ctx context.Context, req *pb.ListOrderBooksReq) (*pb.OrderBooks, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("ListOrderBooks request", "include_deleted", req.IncludeDeleted, "user_id", c.userID)
+
+	orderBooks, err := s.service.ListOrderBooks(req.IncludeDeleted)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to list orderbooks: %v", err)
+	}
+
+	var res pb.OrderBooks
+	for _, orderBook := range orderBooks {
+		res.OrderBooks = append(res.OrderBooks, orderBookToPb(orderBook))
+	}
+	return &res, nil
+}
+
+func (s *Server) CancelOrderBookOrders(ctx context.Context, req *pb.OrderBookSymbol) (*pb.Orders, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("CancelOrderBookOrders request", "symbol", req.Symbol, "user_id", c.userID)
+
+	if req.Symbol == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "symbol is required")
+	}
+
+	orders, err := s.service.CancelOrders(models.OpenOrdersFilter{Symbol: req.Symbol})
+	if err != nil {
+		if err.Error() == "Exchange is recovering" {
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to cancel orderbook orders: %v", err)
+	}
+	return ordersToPb(orders), nil
+}
+
+func (s *Server) GetOrderBook(ctx context.Context, req *pb.OrderBookReq) (*pb.OrderBookSnapshot, error) {
+	s.logger.Info("GetOrderBook request", "symbol", req.Symbol, "depth", req.Depth)
+
+	if req.Depth <= 0 || req.Depth > maxOrderBookDepth {
+		return nil, status.Errorf(codes.InvalidArgument, "depth must be between 1 and %d", maxOrderBookDepth)
+	}
+
+	snapshot, err := s.service.GetOrderBook(req.Symbol, int(req.Depth), req.Grouping)
+	if err != nil {
+		if err.Error() == "Order book not found" {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get orderbook: %v", err)
+	}
+	return orderBookSnapshotToPb(snapshot), nil
+}
+
+func (s *Server) GetOrderBookL3(req *pb.OrderBookSymbol, stream pb.MatchingEngine_GetOrderBookL3Server) error {
+	c, err := requireRole(stream.Context(), roleMarketMaker, roleAuditor, roleAdmin)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("GetOrderBookL3 request", "symbol", req.Symbol, "user_id", c.userID)
+
+	snapshot, err := s.service.GetOrderBookL3(req.Symbol)
+	if err != nil {
+		if err.Error() == "Order book not found" {
+			return status.Errorf(codes.NotFound, err.Error())
+		}
+		return status.Errorf(codes.Internal, "Failed to get orderbook: %v", err)
+	}
+
+	orders := append(snapshot.Bids, snapshot.Asks...)
+	for start := 0; start == 0 || start < len(orders); start += l3ChunkSize {
+		end := min(start+l3ChunkSize, len(orders))
+
+		var chunk = pb.OrderBookL3Chunk{
+			Symbol:   snapshot.Symbol,
+			Sequence: snapshot.Sequence,
+			Last:     end == len(orders),
+		}
+		for _, o := range orders[start:end] {
+			chunk.Orders = append(chunk.Orders, restingOrderToPb(o))
+		}
+
+		if err := stream.Send(&chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) GetCandles(ctx context.Context, req *pb.CandlesReq) (*pb.Candles, error) {
+	s.logger.Info("GetCandles request", "symbol", req.Symbol, "interval", req.Interval)
+
+	if req.From == nil || req.To == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "from and to are required")
+	}
+
+	candles, err := s.service.GetCandles(req.Symbol, req.Interval, req.From.AsTime(), req.To.AsTime())
+	if err != nil {
+		if err.Error() == "unknown candle interval" {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get candles: %v", err)
+	}
+
+	var res pb.Candles
+	for _, c := range candles {
+		res.Candles = append(res.Candles, candleToPb(c))
+	}
+	return &res, nil
+}
+
+func (s *Server) GetTicker(ctx context.Context, req *pb.OrderBookSymbol) (*pb.Tickers, error) {
+	s.logger.Info("GetTicker request", "symbol", req.Symbol)
+
+	tickers, err := s.service.GetTickers(req.Symbol)
+	if err != nil {
+		if err.Error() == "Order book not found" {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get ticker: %v", err)
+	}
+
+	var res pb.Tickers
+	for _, t := range tickers {
+		res.Tickers = append(res.Tickers, tickerToPb(t))
+	}
+	return &res, nil
+}
+
+func (s *Server) SetFeeSchedule(ctx context.Context, req *pb.FeeSchedule) (*emptypb.Empty, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("SetFeeSchedule request", "symbol", req.Symbol, "tiers", len(req.Tiers), "user_id", c.userID)
+
+	var schedule = models.FeeSchedule{Symbol: req.Symbol}
+	for _, tier := range req.Tiers {
+		schedule.Tiers = append(schedule.Tiers, models.FeeTier{
+			MinVolume: tier.MinVolume,
+			MakerRate: tier.MakerRate,
+			TakerRate: tier.TakerRate,
 		})
 	}
+
+	if err := s.service.SetFeeSchedule(schedule); err != nil {
+		switch err.Error() {
+		case "Order book not found":
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		case "Invalid min volume", "Invalid fee rate", "Duplicate fee tier", "Fee schedule must start at zero volume":
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to set fee schedule: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetFeeSchedules(ctx context.Context, req *emptypb.Empty) (*pb.FeeSchedules, error) {
+	c, err := requireRole(ctx, roleAdmin, roleAuditor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetFeeSchedules request", "user_id", c.userID)
+
+	schedules, err := s.service.GetFeeSchedules()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get fee schedules: %v", err)
+	}
+
+	var res pb.FeeSchedules
+	for _, schedule := range schedules {
+		res.FeeSchedules = append(res.FeeSchedules, feeScheduleToPb(schedule))
+	}
 	return &res, nil
 }
 
-func (s *Server) GetOrders(ctx context.Context, req *pb.UserID) (*pb.Orders, error) {
-	s.logger.Info("GetOrders request", "user_id", req.UserID)
+func (s *Server) GetBalances(ctx context.Context, req *pb.BalancesReq) (*pb.Balances, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("GetBalances request", "user_id", req.UserID, "asset", req.Asset, "caller_id", c.userID)
 
-	orders, err := s.service.GetOrders(req.UserID)
+	balances, err := s.service.GetBalances(req.UserID, req.Asset)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get orders: %v", err)
+		return nil, status.Errorf(codes.Internal, "Failed to get balances: %v", err)
 	}
 
-	var res pb.Orders
-	for _, o := range orders {
-		res.Orders = append(res.Orders, &pb.Order{
-			ID:         o.ID,
-			UserID:     o.UserID,
-			IsBid:      o.IsBid,
-			Symbol:     o.Symbol,
-			Price:      o.Price,
-			Qty:        o.Qty,
-			SizeFilled: o.SizeFilled,
-			Status:     o.Status,
-			Type:       o.Type,
-			CreatedAt:  timestamppb.New(o.CreatedAt),
-			ClosedAt:   timestamppb.New(o.ClosedAt.Time),
+	var res pb.Balances
+	for _, balance := range balances {
+		res.Balances = append(res.Balances, &pb.Balance{
+			UserID: balance.UserID,
+			Asset:  balance.Asset,
+			Amount: balance.Amount,
 		})
 	}
 	return &res, nil
 }
 
-func (s *Server) CreateOrderBook(ctx context.Context, req *pb.OrderBookSymbol) (*emptypb.Empty, error) {
-	s.logger.Info("CreateOrderBook request", "symbol", req.Symbol)
+func (s *Server) CheckLedger(ctx context.Context, req *emptypb.Empty) (*pb.LedgerCheck, error) {
+	c, err := requireRole(ctx, roleAdmin, roleAuditor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("CheckLedger request", "user_id", c.userID)
 
-	err := s.service.AddOrderBook(req.Symbol)
+	imbalances, err := s.service.CheckLedger()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to create orderbook: %v", err)
+		return nil, status.Errorf(codes.Internal, "Failed to check ledger: %v", err)
+	}
+
+	var res = pb.LedgerCheck{Balanced: len(imbalances) == 0}
+	for _, imbalance := range imbalances {
+		res.Imbalances = append(res.Imbalances, &pb.LedgerImbalance{
+			Asset: imbalance.Asset,
+			Sum:   imbalance.Sum,
+		})
+	}
+	return &res, nil
+}
+
+func (s *Server) SetUserLimits(ctx context.Context, req *pb.UserLimits) (*emptypb.Empty, error) {
+	c, err := requireRole(ctx, roleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("SetUserLimits request", "limits_user_id", req.UserID, "symbol", req.Symbol, "user_id", c.userID)
+
+	if err := s.service.SetUserLimits(userLimitsFromPb(req)); err != nil {
+		switch err.Error() {
+		case "Order book not found":
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		case "Invalid limits":
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to set user limits: %v", err)
 	}
 	return &emptypb.Empty{}, nil
 }
 
-func (s *Server) DeleteOrderBook(ctx context.Context, req *pb.OrderBookSymbol) (*emptypb.Empty, error) {
-	s.logger.Info("DeleteOrderBook request", "symbol", req.Symbol)
+func (s *Server) GetUserLimits(ctx context.Context, req *pb.UserID) (*pb.UserLimitsList, error) {
+	c, err := requireReader(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
 
-	err := s.service.DeleteOrderBook(req.Symbol)
+	s.logger.Info("GetUserLimits request", "limits_user_id", req.UserID, "user_id", c.userID)
+
+	userLimits, err := s.service.GetUserLimits(req.UserID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to delete orderbook: %v", err)
+		return nil, status.Errorf(codes.Internal, "Failed to get user limits: %v", err)
 	}
-	return &emptypb.Empty{}, nil
+
+	var res pb.UserLimitsList
+	for _, limits := range userLimits {
+		res.UserLimits = append(res.UserLimits, userLimitsToPb(limits))
+	}
+	return &res, nil
 }
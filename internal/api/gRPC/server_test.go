@@ -0,0 +1,92 @@
+package gRPC
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// orderService places and cancels orders in memory and records what reached it
+type orderService struct {
+	service.Exchanger
+	orders   map[int64]models.Order
+	placed   []models.PlaceOrderReq
+	canceled []int64
+}
+
+func (o *orderService) GetOrder(orderID int64) (models.Order, error) {
+	order, ok := o.orders[orderID]
+	if !ok {
+		return models.Order{}, errors.New("Order not found")
+	}
+	return order, nil
+}
+
+func (o *orderService) CancelOrder(orderID int64) (models.Order, error) {
+	o.canceled = append(o.canceled, orderID)
+	order := o.orders[orderID]
+	order.Status = "canceled"
+	return order, nil
+}
+
+func (o *orderService) PlaceOrder(order models.PlaceOrderReq) ([]models.Order, error) {
+	o.placed = append(o.placed, order)
+	return []models.Order{{ID: 1, UserID: order.UserID, Symbol: order.Symbol, Status: "filling"}}, nil
+}
+
+func newTestServer(exchanger service.Exchanger) *Server {
+	return &Server{service: exchanger, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestPlaceOrderForAnotherUser(t *testing.T) {
+	var (
+		exchanger = &orderService{}
+		s         = newTestServer(exchanger)
+		req       = &pb.PlaceOrderReq{UserID: 1, Symbol: "BTC/USDT", Price: "100", Qty: "1", Type: "limit", IsBid: true}
+	)
+
+	for _, role := range []string{roleUser, roleAuditor, roleMarketMaker} {
+		_, err := s.PlaceOrder(callerContext("2", role), req)
+		require.Equal(t, codes.PermissionDenied, status.Code(err), role)
+	}
+	require.Empty(t, exchanger.placed)
+
+	_, err := s.PlaceOrder(callerContext("1", roleUser), req)
+	require.NoError(t, err)
+	require.Len(t, exchanger.placed, 1)
+	require.Equal(t, int64(1), exchanger.placed[0].UserID)
+}
+
+func TestCancelOrderOfAnotherUser(t *testing.T) {
+	var (
+		exchanger = &orderService{orders: map[int64]models.Order{
+			7: {ID: 7, UserID: 1, Symbol: "BTC/USDT", Status: "filling"},
+		}}
+		s = newTestServer(exchanger)
+	)
+
+	for _, role := range []string{roleUser, roleAuditor, roleMarketMaker} {
+		_, err := s.CancelOrder(callerContext("2", role), &pb.OrderID{OrderID: 7})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), role)
+	}
+	require.Empty(t, exchanger.canceled)
+
+	_, err := s.CancelOrder(callerContext("2", roleUser), &pb.OrderID{OrderID: 8})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	for _, ctx := range []context.Context{callerContext("1", roleUser), callerContext("9", roleAdmin)} {
+		order, err := s.CancelOrder(ctx, &pb.OrderID{OrderID: 7})
+		require.NoError(t, err)
+		require.Equal(t, "canceled", order.Status)
+	}
+	require.Equal(t, []int64{7, 7}, exchanger.canceled)
+}
@@ -0,0 +1,150 @@
+package gRPC
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// sessions counts open trading sessions per user, a user may hold several
+// streams at once and orders are canceled only when the last one is gone
+type sessions struct {
+	mu     sync.Mutex
+	byUser map[int64]int
+}
+
+func newSessions() *sessions {
+	return &sessions{
+		byUser: make(map[int64]int),
+	}
+}
+
+func (ss *sessions) open(userID int64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.byUser[userID]++
+}
+
+// close reports whether it was the last open session of the user
+func (ss *sessions) close(userID int64) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.byUser[userID]--
+	if ss.byUser[userID] > 0 {
+		return false
+	}
+	delete(ss.byUser, userID)
+	return true
+}
+
+// checkHeartbeat rejects heartbeats sent on behalf of another user than the
+// session's, a heartbeat without userID belongs to the session's user
+func checkHeartbeat(heartbeat *pb.Heartbeat, userID int64) error {
+	if heartbeat.UserID != 0 && heartbeat.UserID != userID {
+		return status.Errorf(codes.PermissionDenied, "heartbeat of another user is not allowed")
+	}
+	return nil
+}
+
+// TradingSession is a dead man's switch for market makers: the session belongs
+// to the caller, every heartbeat is echoed back and if the stream drops
+// or stays silent longer than SessionHeartbeatTimeout all resting orders
+// of the user are canceled
+func (s *Server) TradingSession(stream pb.MatchingEngine_TradingSessionServer) error {
+	c, err := callerFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	userID := c.userID
+	if err := checkHeartbeat(first, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("TradingSession opened", "user_id", userID)
+	s.sessions.open(userID)
+
+	defer func() {
+		if !s.sessions.close(userID) {
+			s.logger.Info("TradingSession closed", "user_id", userID)
+			return
+		}
+
+		orders, err := s.service.CancelOrders(models.OpenOrdersFilter{UserID: userID})
+		if err != nil {
+			s.logger.Error("Failed to cancel orders on session close", "user_id", userID, "error", err)
+			return
+		}
+		s.logger.Info("TradingSession closed, orders canceled", "user_id", userID, "count", len(orders))
+	}()
+
+	if err := stream.Send(&pb.Heartbeat{UserID: userID, Timestamp: timestamppb.Now()}); err != nil {
+		return err
+	}
+
+	var (
+		heartbeats = make(chan struct{})
+		recvErr    = make(chan error, 1)
+	)
+
+	go func() {
+		for {
+			heartbeat, err := stream.Recv()
+			if err == nil {
+				err = checkHeartbeat(heartbeat, userID)
+			}
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			select {
+			case heartbeats <- struct{}{}:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(s.cfg.SessionHeartbeatTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-heartbeats:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(s.cfg.SessionHeartbeatTimeout)
+
+			if err := stream.Send(&pb.Heartbeat{UserID: userID, Timestamp: timestamppb.Now()}); err != nil {
+				return err
+			}
+
+		case <-timer.C:
+			s.logger.Warn("TradingSession heartbeat timeout", "user_id", userID)
+			return status.Errorf(codes.DeadlineExceeded, "no heartbeat for %s", s.cfg.SessionHeartbeatTimeout)
+
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
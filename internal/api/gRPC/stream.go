@@ -0,0 +1,262 @@
+package gRPC
+
+import (
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const tradesBackfillPage = 500
+
+func (s *Server) SubscribeOrderBook(req *pb.OrderBookSymbol, stream pb.MatchingEngine_SubscribeOrderBookServer) error {
+	s.logger.Info("SubscribeOrderBook request", "symbol", req.Symbol)
+
+	snapshot, updates, unsubscribe, err := s.service.SubscribeOrderBook(req.Symbol)
+	if err != nil {
+		if err.Error() == "Order book not found" {
+			return status.Errorf(codes.NotFound, err.Error())
+		}
+		return status.Errorf(codes.Internal, "Failed to subscribe to orderbook: %v", err)
+	}
+	defer unsubscribe()
+
+	err = stream.Send(&pb.OrderBookUpdate{
+		Update: &pb.OrderBookUpdate_Snapshot{Snapshot: orderBookSnapshotToPb(snapshot)},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return status.Errorf(codes.Aborted, "subscriber is too slow, resubscribe to get a new snapshot")
+			}
+
+			err := stream.Send(&pb.OrderBookUpdate{
+				Update: &pb.OrderBookUpdate_Level{Level: priceLevelUpdateToPb(update)},
+			})
+			if err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// SubscribeTrades streams the public trade tape. With fromTradeID set the
+// trades starting from that ID are read from the database first
+func (s *Server) SubscribeTrades(req *pb.TradesReq, stream pb.MatchingEngine_SubscribeTradesServer) error {
+	s.logger.Info("SubscribeTrades request", "symbol", req.Symbol, "from_trade_id", req.FromTradeID)
+
+	// subscribe before the backfill so that nothing is missed in between
+	trades, unsubscribe := s.service.SubscribeTrades()
+	defer unsubscribe()
+
+	var tape = newTradeTape(s, stream, req.Symbol, req.FromTradeID)
+	if req.FromTradeID > 0 {
+		if err := tape.backfill(); err != nil {
+			return err
+		}
+	}
+
+	// the feed is a new source, the first trade of every symbol is checked for a gap again
+	tape.contiguous = make(map[string]bool)
+	for {
+		select {
+		case trade, ok := <-trades:
+			if !ok {
+				return status.Errorf(codes.Aborted, "subscriber is too slow, resubscribe from the last received trade ID")
+			}
+
+			if req.Symbol != "" && trade.Symbol != req.Symbol {
+				continue
+			}
+
+			if err := tape.send(trade); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// tradeTape sends every trade of a subscription once and in ID order per symbol.
+// The trades of a book are stored and published in the order they matched but
+// books commit independently, so the IDs of different symbols interleave and a
+// trade may commit after a later page of the backfill was read. Whenever the next
+// trade of a symbol can not be known to follow the symbol's cursor the trades
+// stored in between are read back
+type tradeTape struct {
+	s      *Server
+	stream pb.MatchingEngine_SubscribeTradesServer
+	symbol string
+
+	backfilled bool
+	floor      int64            //trades at or below it are never sent
+	cursors    map[string]int64 //last trade sent per symbol
+	contiguous map[string]bool  //symbols whose next trade from the source follows the cursor
+}
+
+func newTradeTape(s *Server, stream pb.MatchingEngine_SubscribeTradesServer, symbol string, fromTradeID int64) *tradeTape {
+	return &tradeTape{
+		s:          s,
+		stream:     stream,
+		symbol:     symbol,
+		backfilled: fromTradeID > 0,
+		floor:      fromTradeID - 1,
+		cursors:    make(map[string]int64),
+		contiguous: make(map[string]bool),
+	}
+}
+
+// backfill sends the stored trades from the floor on
+func (t *tradeTape) backfill() error {
+	var afterTradeID = t.floor
+	for {
+		if err := t.stream.Context().Err(); err != nil {
+			return err
+		}
+
+		trades, err := t.s.service.GetTrades(t.symbol, afterTradeID, tradesBackfillPage)
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to get trades: %v", err)
+		}
+
+		// pages of a single symbol follow each other, pages of every symbol
+		// may miss trades of a symbol that committed late
+		if t.symbol == "" {
+			t.contiguous = make(map[string]bool)
+		}
+
+		for _, trade := range trades {
+			if err := t.send(trade); err != nil {
+				return err
+			}
+			afterTradeID = trade.ID
+		}
+
+		if len(trades) < tradesBackfillPage {
+			return nil
+		}
+	}
+}
+
+// send sends the trade unless it was sent already, along with the stored trades
+// of its symbol between the cursor and the trade when they may have been missed
+func (t *tradeTape) send(trade models.Trade) error {
+	cursor, ok := t.cursors[trade.Symbol]
+	if !ok {
+		if !t.backfilled {
+			// without a backfill the tape starts at the first trade published
+			cursor = trade.ID - 1
+			t.contiguous[trade.Symbol] = true
+		} else {
+			cursor = t.floor
+		}
+	}
+
+	if trade.ID <= cursor {
+		return nil
+	}
+
+	if !t.contiguous[trade.Symbol] {
+		t.contiguous[trade.Symbol] = true
+		return t.fill(trade.Symbol, cursor, trade.ID)
+	}
+
+	if err := t.stream.Send(tradeToPb(trade)); err != nil {
+		return err
+	}
+	t.cursors[trade.Symbol] = trade.ID
+	return nil
+}
+
+// fill sends the stored trades of symbol after cursor up to and including untilTradeID
+func (t *tradeTape) fill(symbol string, cursor, untilTradeID int64) error {
+	for cursor < untilTradeID {
+		trades, err := t.s.service.GetTrades(symbol, cursor, tradesBackfillPage)
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to get trades: %v", err)
+		}
+
+		for _, trade := range trades {
+			if trade.ID > untilTradeID {
+				return nil
+			}
+			if err := t.stream.Send(tradeToPb(trade)); err != nil {
+				return err
+			}
+			cursor = trade.ID
+			t.cursors[symbol] = cursor
+		}
+
+		if len(trades) < tradesBackfillPage {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *Server) SubscribeExecutions(req *pb.UserID, stream pb.MatchingEngine_SubscribeExecutionsServer) error {
+	c, err := requireReader(stream.Context(), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("SubscribeExecutions request", "executions_user_id", req.UserID, "user_id", c.userID)
+
+	reports, unsubscribe := s.service.SubscribeExecutions(req.UserID)
+	defer unsubscribe()
+
+	for {
+		select {
+		case report, ok := <-reports:
+			if !ok {
+				return status.Errorf(codes.Aborted, "subscriber is too slow, resubscribe and reconcile with GetCurrentOrders")
+			}
+
+			if err := stream.Send(executionReportToPb(report)); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) SubscribeCandles(req *pb.CandlesReq, stream pb.MatchingEngine_SubscribeCandlesServer) error {
+	s.logger.Info("SubscribeCandles request", "symbol", req.Symbol, "interval", req.Interval)
+
+	candles, unsubscribe, err := s.service.SubscribeCandles(req.Symbol, req.Interval)
+	if err != nil {
+		if err.Error() == "unknown candle interval" {
+			return status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return status.Errorf(codes.Internal, "Failed to subscribe to candles: %v", err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case candle, ok := <-candles:
+			if !ok {
+				return status.Errorf(codes.Aborted, "subscriber is too slow, resubscribe")
+			}
+
+			if err := stream.Send(candleToPb(candle)); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gRPC
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	pb "github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// storedTrades serves GetTrades from memory, trades are stored in commit order
+type storedTrades struct {
+	service.Exchanger
+	trades  []models.Trade
+	queries int
+}
+
+func (st *storedTrades) GetTrades(symbol string, afterTradeID int64, limit int) ([]models.Trade, error) {
+	st.queries++
+
+	var trades []models.Trade
+	for _, trade := range st.trades {
+		if (symbol == "" || trade.Symbol == symbol) && trade.ID > afterTradeID {
+			trades = append(trades, trade)
+		}
+	}
+	// the database returns trades in ID order
+	sort.Slice(trades, func(i, j int) bool { return trades[i].ID < trades[j].ID })
+	if len(trades) > limit {
+		trades = trades[:limit]
+	}
+	return trades, nil
+}
+
+type tradesStream struct {
+	grpc.ServerStream
+	sent []int64
+}
+
+func (ts *tradesStream) Send(trade *pb.Trade) error {
+	ts.sent = append(ts.sent, trade.ID)
+	return nil
+}
+
+func (ts *tradesStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestTradeTape(t *testing.T) {
+	var (
+		store = &storedTrades{trades: []models.Trade{
+			{ID: 1, Symbol: "BTC/USDT"},
+			{ID: 3, Symbol: "BTC/USDT"},
+			{ID: 4, Symbol: "ETH/USDT"},
+		}}
+		stream = &tradesStream{}
+		tape   = newTradeTape(&Server{service: store}, stream, "", 1)
+	)
+
+	require.NoError(t, tape.backfill())
+	require.Equal(t, []int64{1, 3, 4}, stream.sent)
+
+	// trade 2 of SOL/USDT committed after the backfill read past its ID
+	store.trades = append(store.trades,
+		models.Trade{ID: 2, Symbol: "SOL/USDT"},
+		models.Trade{ID: 5, Symbol: "BTC/USDT"},
+		models.Trade{ID: 6, Symbol: "BTC/USDT"},
+		models.Trade{ID: 7, Symbol: "ETH/USDT"},
+		models.Trade{ID: 8, Symbol: "BTC/USDT"},
+	)
+	tape.contiguous = make(map[string]bool)
+	stream.sent, store.queries = nil, 0
+
+	for _, trade := range []models.Trade{
+		{ID: 3, Symbol: "BTC/USDT"}, //sent by the backfill
+		{ID: 6, Symbol: "BTC/USDT"}, //the first of the feed, trade 5 is read back with it
+		{ID: 5, Symbol: "BTC/USDT"},
+		{ID: 2, Symbol: "SOL/USDT"},
+		{ID: 7, Symbol: "ETH/USDT"},
+		{ID: 8, Symbol: "BTC/USDT"},
+	} {
+		require.NoError(t, tape.send(trade))
+	}
+	require.Equal(t, []int64{5, 6, 2, 7, 8}, stream.sent)
+	// one read back per symbol, later trades of the feed follow without a gap
+	require.Equal(t, 3, store.queries)
+}
+
+func TestTradeTapeWithoutBackfill(t *testing.T) {
+	var (
+		store  = &storedTrades{trades: []models.Trade{{ID: 1, Symbol: "BTC/USDT"}}}
+		stream = &tradesStream{}
+		tape   = newTradeTape(&Server{service: store}, stream, "BTC/USDT", 0)
+	)
+
+	// the tape starts at the first trade published and reads nothing back
+	require.NoError(t, tape.send(models.Trade{ID: 2, Symbol: "BTC/USDT"}))
+	require.NoError(t, tape.send(models.Trade{ID: 3, Symbol: "BTC/USDT"}))
+	require.Equal(t, []int64{2, 3}, stream.sent)
+	require.Zero(t, store.queries)
+}
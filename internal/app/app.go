@@ -1,11 +1,19 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"github.com/BazaarTrade/OrderMatchingService/internal/api/gRPC"
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/BazaarTrade/OrderMatchingService/internal/publisher"
+	"github.com/BazaarTrade/OrderMatchingService/internal/publisher/kafka"
+	"github.com/BazaarTrade/OrderMatchingService/internal/publisher/memory"
 	"github.com/BazaarTrade/OrderMatchingService/internal/repository/postgres"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk/balance"
+	ledger "github.com/BazaarTrade/OrderMatchingService/internal/risk/memory"
 	"github.com/BazaarTrade/OrderMatchingService/internal/service/exchange.go"
 )
 
@@ -15,13 +23,57 @@ func Run() {
 
 	logger.Info("Starting aplication")
 
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return
+	}
+
 	repo, err := postgres.NewPostgres("user=postgres password=postgres dbname=postgres sslmode=disable host=localhost port=5432", logger)
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		return
 	}
 
-	service := exchange.NewExchange(repo, logger)
-	server := gRPC.NewServer(service, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var pub publisher.Publisher
+	switch cfg.Publisher {
+	case "kafka":
+		pub = kafka.New(cfg.KafkaBrokers, cfg.KafkaTopicPrefix)
+	default:
+		logger.Warn("Outbox events are kept in memory and not delivered to other services")
+		pub = memory.New()
+	}
+	defer pub.Close()
+
+	go publisher.NewRelay(repo, pub, cfg.OutboxPollInterval, cfg.OutboxBatchSize, logger).Run(ctx)
+
+	var riskChecker risk.Checker
+	switch cfg.RiskChecker {
+	case "grpc":
+		client, err := balance.New(cfg.BalanceServiceAddr)
+		if err != nil {
+			logger.Error("Failed to connect to balance service", "error", err)
+			return
+		}
+		defer client.Close()
+		riskChecker = client
+	case "memory":
+		logger.Warn("Balances are kept in memory and lost on restart")
+		riskChecker = ledger.New(cfg.MemoryLedgerBalances)
+	default:
+		logger.Warn("Risk checks are disabled, orders are placed without reserving funds")
+		riskChecker = risk.Disabled{}
+	}
+
+	service := exchange.NewExchange(repo, riskChecker, cfg, logger)
+	if err := service.Start(ctx); err != nil {
+		logger.Error("Failed to start exchange", "error", err)
+		return
+	}
+
+	server := gRPC.NewServer(service, cfg, logger)
 	server.StartGRPCServer()
 }
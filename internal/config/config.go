@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Config struct {
+	// SessionHeartbeatTimeout is how long a trading session may stay silent
+	// before all of the user's resting orders are canceled
+	SessionHeartbeatTimeout time.Duration
+
+	// JournalPath is the file every engine command is written to before it is applied,
+	// an empty path disables the journal
+	JournalPath string
+
+	// SnapshotDir is where the order books are snapshotted every SnapshotInterval so that
+	// recovery only replays the journal written after the latest snapshot.
+	// An empty dir or a zero interval disables snapshots
+	SnapshotDir      string
+	SnapshotInterval time.Duration
+
+	// Publisher is where outbox events are relayed to, "kafka" or "memory".
+	// The memory publisher keeps events in process and is meant for local runs
+	Publisher        string
+	KafkaBrokers     []string
+	KafkaTopicPrefix string
+
+	// OutboxPollInterval is how often the relay looks for new events once the
+	// outbox is drained, it reads at most OutboxBatchSize events at a time
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+
+	// RiskChecker is what reserves the funds of an order before it is placed,
+	// "grpc" for the balance service at BalanceServiceAddr, "memory" for an
+	// in-process ledger crediting every new account with MemoryLedgerBalances,
+	// or "disabled" to trust that every user can pay
+	RiskChecker          string
+	BalanceServiceAddr   string
+	MemoryLedgerBalances map[string]decimal.Decimal
+
+	// OrderRateLimit, CancelRateLimit and QueryRateLimit are the request budgets
+	// of every user for placing orders, canceling them and everything else
+	OrderRateLimit  RateLimit
+	CancelRateLimit RateLimit
+	QueryRateLimit  RateLimit
+}
+
+// RateLimit is a token bucket refilled with PerSecond tokens every second up to Burst
+type RateLimit struct {
+	PerSecond int
+	Burst     int
+}
+
+func Load() (Config, error) {
+	var (
+		cfg Config
+		err error
+	)
+
+	cfg.SessionHeartbeatTimeout, err = getDuration("SESSION_HEARTBEAT_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.JournalPath = getString("JOURNAL_PATH", "data/commands.journal")
+	cfg.SnapshotDir = getString("SNAPSHOT_DIR", "data/snapshots")
+
+	cfg.SnapshotInterval, err = getDuration("SNAPSHOT_INTERVAL", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.Publisher = getString("OUTBOX_PUBLISHER", "memory")
+	if cfg.Publisher != "memory" && cfg.Publisher != "kafka" {
+		return Config{}, fmt.Errorf("invalid OUTBOX_PUBLISHER: %q", cfg.Publisher)
+	}
+
+	cfg.KafkaBrokers = getList("KAFKA_BROKERS", []string{"localhost:9092"})
+	cfg.KafkaTopicPrefix = getString("KAFKA_TOPIC_PREFIX", "orderMatching.")
+
+	cfg.OutboxPollInterval, err = getDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.OutboxBatchSize, err = getInt("OUTBOX_BATCH_SIZE", 100)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.RiskChecker = getString("RISK_CHECKER", "disabled")
+	if cfg.RiskChecker != "disabled" && cfg.RiskChecker != "memory" && cfg.RiskChecker != "grpc" {
+		return Config{}, fmt.Errorf("invalid RISK_CHECKER: %q", cfg.RiskChecker)
+	}
+
+	cfg.BalanceServiceAddr = getString("BALANCE_SERVICE_ADDR", "localhost:50052")
+
+	cfg.MemoryLedgerBalances, err = getAmounts("MEMORY_LEDGER_BALANCES")
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.OrderRateLimit, err = getRateLimit("ORDER_RATE_LIMIT", RateLimit{PerSecond: 20, Burst: 40})
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.CancelRateLimit, err = getRateLimit("CANCEL_RATE_LIMIT", RateLimit{PerSecond: 50, Burst: 100})
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.QueryRateLimit, err = getRateLimit("QUERY_RATE_LIMIT", RateLimit{PerSecond: 20, Burst: 40})
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func getString(key, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// getList splits a comma separated value, dropping empty items
+func getList(key string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getAmounts parses a comma separated list of ASSET=amount pairs such as "USDT=10000,BTC=1"
+func getAmounts(key string) (map[string]decimal.Decimal, error) {
+	amounts := make(map[string]decimal.Decimal)
+	for _, item := range getList(key, nil) {
+		asset, value, ok := strings.Cut(item, "=")
+		if !ok || asset == "" {
+			return nil, fmt.Errorf("invalid %s item: %q", key, item)
+		}
+		amount, err := decimal.NewFromString(value)
+		if err != nil || amount.IsNegative() {
+			return nil, fmt.Errorf("invalid %s amount: %q", key, item)
+		}
+		amounts[asset] = amount
+	}
+	return amounts, nil
+}
+
+// getRateLimit reads key + "_PER_SECOND" and key + "_BURST"
+func getRateLimit(key string, defaultValue RateLimit) (RateLimit, error) {
+	perSecond, err := getInt(key+"_PER_SECOND", defaultValue.PerSecond)
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	burst, err := getInt(key+"_BURST", defaultValue.Burst)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	return RateLimit{PerSecond: perSecond, Burst: burst}, nil
+}
+
+func getInt(key string, defaultValue int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return n, nil
+}
+
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q must be positive", key, value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Candle struct {
+	Symbol      string
+	Interval    string
+	OpenTime    time.Time
+	CloseTime   time.Time
+	Open        string
+	High        string
+	Low         string
+	Close       string
+	Volume      string
+	QuoteVolume string
+	TradeCount  int
+	Closed      bool
+}
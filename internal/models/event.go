@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	EventTopicOrders = "orders"
+	EventTopicTrades = "trades"
+)
+
+// Event is an outbox entry, written in the same transaction as the change it
+// describes and published afterwards at least once, consumers dedupe by ID
+type Event struct {
+	ID        int64
+	Topic     string
+	Key       string //events with the same key are published in order
+	Payload   []byte //JSON encoded OrderEvent or TradeEvent
+	CreatedAt time.Time
+}
+
+type OrderEvent struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"userID"`
+	IsBid         bool       `json:"isBid"`
+	Symbol        string     `json:"symbol"`
+	Price         string     `json:"price"`
+	Qty           string     `json:"qty"`
+	SizeFilled    string     `json:"sizeFilled"`
+	Status        string     `json:"status"`
+	Type          string     `json:"type"`
+	CancelReason  string     `json:"cancelReason,omitempty"`
+	ClientOrderID string     `json:"clientOrderID,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty"`
+}
+
+type TradeEvent struct {
+	ID           int64     `json:"id"`
+	Symbol       string    `json:"symbol"`
+	Price        string    `json:"price"`
+	Qty          string    `json:"qty"`
+	IsBid        bool      `json:"isBid"`
+	MakerOrderID int64     `json:"makerOrderID"`
+	TakerOrderID int64     `json:"takerOrderID"`
+	MakerFee     string    `json:"makerFee"`
+	TakerFee     string    `json:"takerFee"`
+	FeeCurrency  string    `json:"feeCurrency"`
+	ExecutedAt   time.Time `json:"executedAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	ExecTypeNew         = "new"
+	ExecTypePartialFill = "partial_fill"
+	ExecTypeFill        = "fill"
+	ExecTypeCanceled    = "canceled"
+	ExecTypeRejected    = "rejected"
+)
+
+const (
+	LiquidityMaker = "maker"
+	LiquidityTaker = "taker"
+)
+
+// ExecutionReport tells the owner of Order what just happened to it.
+// Trade fields are set for fills only, Reason for rejections
+type ExecutionReport struct {
+	ExecType       string
+	Order          Order
+	TradeID        int64
+	LastQty        string
+	LastPrice      string
+	CounterOrderID int64
+	Liquidity      string //whether the order made or took the liquidity of the trade
+	Fee            string //paid by the owner on the trade, negative for a rebate
+	FeeCurrency    string
+	Reason         string
+	CreatedAt      time.Time
+}
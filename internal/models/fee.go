@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// FeeTier applies to users whose rolling 30 day volume in the symbol, counted in
+// the quote currency, is at least MinVolume. Rates are fractions of the trade value,
+// a negative MakerRate is a rebate paid to the maker
+type FeeTier struct {
+	MinVolume string
+	MakerRate string
+	TakerRate string
+}
+
+// FeeSchedule holds the tiers of one symbol ordered by MinVolume, a symbol
+// without one trades free of fees
+type FeeSchedule struct {
+	Symbol string
+	Tiers  []FeeTier
+}
+
+// UserVolume is the quote volume a user traded in a symbol during one day
+type UserVolume struct {
+	UserID int64
+	Symbol string
+	Day    time.Time
+	Volume string
+}
@@ -0,0 +1,26 @@
+package models
+
+// FeeAccountUserID is the account trading fees are posted to
+const FeeAccountUserID int64 = 0
+
+// LedgerEntry moves Amount of Asset into the user's account, out of it when
+// negative. The entries posted for a trade sum to zero per asset
+type LedgerEntry struct {
+	TradeID int64
+	UserID  int64
+	Asset   string
+	Amount  string
+}
+
+// Balance is the net amount of an asset a user gained or gave up by trading
+type Balance struct {
+	UserID int64
+	Asset  string
+	Amount string
+}
+
+// LedgerImbalance is an asset whose entries do not sum to zero across all accounts
+type LedgerImbalance struct {
+	Asset string
+	Sum   string
+}
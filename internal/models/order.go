@@ -17,6 +17,8 @@ type Order struct {
 	Type       string
 	CreatedAt  time.Time
 	ClosedAt   sql.NullTime
+	// ClientOrderID is the caller's own ID for the order, unique per user, empty when not given
+	ClientOrderID string
 }
 
 type Match struct {
@@ -31,4 +33,72 @@ type PlaceOrderReq struct {
 	Price  string
 	Qty    string
 	Type   string //Market or Limit
+	// ClientOrderID makes placement idempotent: placing again with an ID the user
+	// already used returns the order placed first
+	ClientOrderID string
+}
+
+// OrdersFilter selects a page of a user's order history, newest first. Empty fields
+// match every order, the created time range is [CreatedFrom, CreatedTo)
+type OrdersFilter struct {
+	UserID      int64
+	Symbol      string
+	IsBid       *bool
+	Type        string
+	Statuses    []string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	After       *OrderCursor //the page starts right after this order
+	Limit       int
+}
+
+// OrderCursor is the position of an order in the history, which is ordered by (CreatedAt, ID)
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+type OpenOrdersFilter struct {
+	UserID int64  //0 matches any user
+	Symbol string //empty matches any symbol
+	IsBid  *bool  //nil matches both sides
+}
+
+type Trade struct {
+	ID           int64
+	Symbol       string
+	Price        string
+	Qty          string
+	IsBid        bool   //side of the taker
+	MakerOrderID int64  //resting order
+	TakerOrderID int64  //aggressor order
+	MakerFee     string //negative for a rebate
+	TakerFee     string
+	FeeCurrency  string
+	ExecutedAt   time.Time
+}
+
+// Fill is a trade seen from one of its orders
+type Fill struct {
+	TradeID        int64
+	OrderID        int64
+	UserID         int64
+	CounterOrderID int64
+	Symbol         string
+	IsBid          bool //side of the order
+	Price          string
+	Qty            string
+	Liquidity      string //whether the order made or took the liquidity of the trade
+	Fee            string //paid by the owner on the trade, negative for a rebate
+	FeeCurrency    string
+	ExecutedAt     time.Time
+}
+
+// FillsFilter selects the fills of a user, zero fields match any value
+type FillsFilter struct {
+	UserID int64
+	Symbol string
+	From   time.Time
+	To     time.Time
+	Limit  int
 }
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// OrderBook is the registry entry of a tradable symbol
+type OrderBook struct {
+	Symbol    string
+	Status    string //active or deleted
+	TickSize  string //limit prices must be a multiple of it, zero allows any price
+	LotSize   string //quantities must be a multiple of it, zero allows any qty
+	MinQty    string
+	CreatedAt time.Time
+	DeletedAt sql.NullTime
+
+	// DelistAt is when a scheduled deletion takes place, DelistCancelOrders
+	// and DelistReason are what it was scheduled with
+	DelistAt           sql.NullTime
+	DelistCancelOrders bool
+	DelistReason       string
+}
+
+// SplitSymbol returns the base and quote assets of a BASE/QUOTE symbol,
+// BTC and USDT for BTC/USDT
+func SplitSymbol(symbol string) (base, quote string, err error) {
+	base, quote, ok := strings.Cut(symbol, "/")
+	if !ok || base == "" || quote == "" || strings.Contains(quote, "/") {
+		return "", "", errors.New("Invalid symbol")
+	}
+	return base, quote, nil
+}
+
+type DeleteOrderBookReq struct {
+	Symbol       string
+	CancelOrders bool      //cancel resting orders instead of refusing to delete the book
+	Reason       string    //stored on the canceled orders
+	ScheduledAt  time.Time //zero or past deletes right away
+}
+
+type OrderBookSnapshot struct {
+	Symbol   string
+	Sequence uint64
+	Bids     []PriceLevel
+	Asks     []PriceLevel
+}
+
+type PriceLevel struct {
+	Price      string
+	Size       string
+	OrderCount int
+}
+
+type PriceLevelUpdate struct {
+	Symbol     string
+	Sequence   uint64
+	IsBid      bool
+	Price      string
+	Size       string //zero size means the level is gone
+	OrderCount int
+}
+
+type OrderBookL3Snapshot struct {
+	Symbol   string
+	Sequence uint64
+	Bids     []RestingOrder
+	Asks     []RestingOrder
+}
+
+type RestingOrder struct {
+	ID            int64
+	UserID        int64
+	IsBid         bool
+	Price         string
+	Qty           string //remaining qty
+	QueuePosition int    //1 is the next order to be filled at its price
+	CreatedAt     time.Time
+}
+
+// Ticker is the top of the book with rolling 24h trade statistics,
+// the statistics are empty when nothing was traded during the last 24h
+type Ticker struct {
+	Symbol             string
+	LastPrice          string
+	BestBid            string
+	BestBidSize        string
+	BestAsk            string
+	BestAskSize        string
+	Open               string
+	High               string
+	Low                string
+	Volume             string
+	QuoteVolume        string
+	PriceChange        string
+	PriceChangePercent string
+	VWAP               string
+	TradeCount         int
+}
@@ -0,0 +1,12 @@
+package models
+
+// UserLimits caps what a user may have open in a symbol. UserID 0 sets the limits of
+// every user and an empty Symbol those of every symbol, the most specific limits
+// of a user and symbol apply as a whole. A zero limit is no limit
+type UserLimits struct {
+	UserID             int64
+	Symbol             string
+	MaxOpenOrders      int
+	MaxRestingNotional string //per side, in the quote currency
+	MaxOrderQty        string
+}
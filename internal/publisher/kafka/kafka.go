@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// EventIDHeader carries the outbox event ID for consumers to dedupe on
+const EventIDHeader = "event-id"
+
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// Publisher writes every event to the topic topicPrefix + event.Topic. Messages are
+// keyed by event key so the events of one order or symbol land on one partition in order
+type Publisher struct {
+	writer      writer
+	topicPrefix string
+}
+
+func New(brokers []string, topicPrefix string) *Publisher {
+	return &Publisher{
+		writer: &kafkago.Writer{
+			Addr:                   kafkago.TCP(brokers...),
+			Balancer:               &kafkago.Hash{},
+			RequiredAcks:           kafkago.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		topicPrefix: topicPrefix,
+	}
+}
+
+func (p *Publisher) Publish(ctx context.Context, events []models.Event) error {
+	messages := make([]kafkago.Message, 0, len(events))
+	for _, event := range events {
+		messages = append(messages, kafkago.Message{
+			Topic: p.topicPrefix + event.Topic,
+			Key:   []byte(event.Key),
+			Value: event.Payload,
+			Time:  event.CreatedAt,
+			Headers: []kafkago.Header{
+				{Key: EventIDHeader, Value: []byte(strconv.FormatInt(event.ID, 10))},
+			},
+		})
+	}
+	return p.writer.WriteMessages(ctx, messages...)
+}
+
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
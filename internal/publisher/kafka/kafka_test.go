@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWriter struct {
+	messages []kafkago.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	var (
+		writer    = &fakeWriter{}
+		pub       = &Publisher{writer: writer, topicPrefix: "orderMatching."}
+		createdAt = time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+	)
+
+	err := pub.Publish(context.Background(), []models.Event{
+		{ID: 7, Topic: models.EventTopicOrders, Key: "1", Payload: []byte(`{"id":1}`), CreatedAt: createdAt},
+		{ID: 8, Topic: models.EventTopicTrades, Key: "BTC/USDT", Payload: []byte(`{"id":1}`), CreatedAt: createdAt},
+	})
+	require.NoError(t, err)
+	require.Len(t, writer.messages, 2)
+
+	message := writer.messages[0]
+	require.Equal(t, "orderMatching.orders", message.Topic)
+	require.Equal(t, "1", string(message.Key))
+	require.Equal(t, `{"id":1}`, string(message.Value))
+	require.Equal(t, createdAt, message.Time)
+	require.Equal(t, []kafkago.Header{{Key: EventIDHeader, Value: []byte("7")}}, message.Headers)
+
+	require.Equal(t, "orderMatching.trades", writer.messages[1].Topic)
+}
+
+// TestPublishToBroker runs against a real broker, e.g. KAFKA_BROKERS=localhost:9092
+func TestPublishToBroker(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS is not set")
+	}
+
+	pub := New(strings.Split(brokers, ","), "orderMatchingTest.")
+	defer pub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := pub.Publish(ctx, []models.Event{
+		{ID: 1, Topic: models.EventTopicOrders, Key: "1", Payload: []byte(`{"id":1}`), CreatedAt: time.Now()},
+	})
+	require.NoError(t, err)
+}
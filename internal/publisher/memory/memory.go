@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// Publisher keeps published events in memory, for local runs and tests
+type Publisher struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func New() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(ctx context.Context, events []models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns everything published so far in publishing order
+func (p *Publisher) Events() []models.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]models.Event, len(p.events))
+	copy(events, p.events)
+	return events
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
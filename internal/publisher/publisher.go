@@ -0,0 +1,15 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// Publisher delivers outbox events to downstream services. Publish returns nil
+// only once every event is accepted, an event may be delivered more than once
+// so consumers dedupe by event ID
+type Publisher interface {
+	Publish(ctx context.Context, events []models.Event) error
+	Close() error
+}
@@ -0,0 +1,86 @@
+package publisher
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// Outbox is the part of the repository the relay reads events from
+type Outbox interface {
+	GetUnpublishedEvents(limit int) ([]models.Event, error)
+	MarkEventsPublished(eventIDs []int64) error
+}
+
+// Relay moves events from the outbox to the publisher. Events are marked published
+// only after the publisher accepted them, so a crash in between publishes them again
+type Relay struct {
+	outbox       Outbox
+	publisher    Publisher
+	pollInterval time.Duration
+	batchSize    int
+	logger       *slog.Logger
+}
+
+func NewRelay(outbox Outbox, publisher Publisher, pollInterval time.Duration, batchSize int, logger *slog.Logger) *Relay {
+	return &Relay{
+		outbox:       outbox,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// Run relays batches back to back while the outbox is full and polls it every
+// pollInterval once drained or after a failure
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			count, err := r.relay(ctx)
+			if err != nil {
+				r.logger.Error("Failed to relay events", "error", err)
+				break
+			}
+			if count < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	events, err := r.outbox.GetUnpublishedEvents(r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := r.publisher.Publish(ctx, events); err != nil {
+		return 0, err
+	}
+
+	eventIDs := make([]int64, 0, len(events))
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.ID)
+	}
+
+	if err := r.outbox.MarkEventsPublished(eventIDs); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
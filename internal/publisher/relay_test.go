@@ -0,0 +1,84 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/publisher/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOutbox struct {
+	events    []models.Event
+	published map[int64]bool
+}
+
+func (o *fakeOutbox) GetUnpublishedEvents(limit int) ([]models.Event, error) {
+	var events []models.Event
+	for _, event := range o.events {
+		if !o.published[event.ID] && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (o *fakeOutbox) MarkEventsPublished(eventIDs []int64) error {
+	for _, id := range eventIDs {
+		o.published[id] = true
+	}
+	return nil
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, events []models.Event) error {
+	return errors.New("broker unavailable")
+}
+
+func (failingPublisher) Close() error {
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	var (
+		outbox = &fakeOutbox{published: make(map[int64]bool)}
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	)
+	for id := int64(1); id <= 3; id++ {
+		outbox.events = append(outbox.events, models.Event{ID: id, Topic: models.EventTopicOrders})
+	}
+
+	// events stay in the outbox while the publisher fails
+	_, err := NewRelay(outbox, failingPublisher{}, 0, 2, logger).relay(context.Background())
+	require.Error(t, err)
+	require.Empty(t, outbox.published)
+
+	var (
+		pub   = memory.New()
+		relay = NewRelay(outbox, pub, 0, 2, logger)
+	)
+
+	count, err := relay.relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	count, err = relay.relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	count, err = relay.relay(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	events := pub.Events()
+	require.Len(t, events, 3)
+	for i, event := range events {
+		require.Equal(t, int64(i+1), event.ID)
+	}
+	require.Len(t, outbox.published, 3)
+}
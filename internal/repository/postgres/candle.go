@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+func (p *Postgres) SaveCandles(candles []models.Candle) error {
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	for _, candle := range candles {
+		_, err = tx.Exec(context.Background(), `
+			INSERT INTO candles (symbol, period, openTime, open, high, low, close, volume, quoteVolume, tradeCount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (symbol, period, openTime) DO UPDATE SET
+			open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low, close = EXCLUDED.close,
+			volume = EXCLUDED.volume, quoteVolume = EXCLUDED.quoteVolume, tradeCount = EXCLUDED.tradeCount
+		`, candle.Symbol, candle.Interval, candle.OpenTime, candle.Open, candle.High, candle.Low,
+			candle.Close, candle.Volume, candle.QuoteVolume, candle.TradeCount)
+		if err != nil {
+			p.logger.Error("Error inserting candle", "error", err)
+			return err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetCandles(symbol, interval string, from, to time.Time, limit int) ([]models.Candle, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT symbol, period, openTime, open, high, low, close, volume, quoteVolume, tradeCount
+	FROM candles
+	WHERE symbol = $1 AND period = $2 AND openTime >= $3 AND openTime < $4
+	ORDER BY openTime
+	LIMIT $5
+	`, symbol, interval, from, to, limit)
+	if err != nil {
+		p.logger.Error("Error selecting candles", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []models.Candle
+	for rows.Next() {
+		var candle = models.Candle{Closed: true}
+		err := rows.Scan(
+			&candle.Symbol,
+			&candle.Interval,
+			&candle.OpenTime,
+			&candle.Open,
+			&candle.High,
+			&candle.Low,
+			&candle.Close,
+			&candle.Volume,
+			&candle.QuoteVolume,
+			&candle.TradeCount,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning candle", "error", err)
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+func (p *Postgres) GetLastCandleOpenTimes() (map[string]map[string]time.Time, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT symbol, period, MAX(openTime)
+	FROM candles
+	GROUP BY symbol, period
+	`)
+	if err != nil {
+		p.logger.Error("Error selecting candles", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var openTimes = make(map[string]map[string]time.Time)
+	for rows.Next() {
+		var (
+			symbol   string
+			interval string
+			openTime time.Time
+		)
+		err := rows.Scan(&symbol, &interval, &openTime)
+		if err != nil {
+			p.logger.Error("Error scanning candle", "error", err)
+			return nil, err
+		}
+		if openTimes[symbol] == nil {
+			openTimes[symbol] = make(map[string]time.Time)
+		}
+		openTimes[symbol][interval] = openTime
+	}
+	return openTimes, nil
+}
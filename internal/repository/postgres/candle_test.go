@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveCandles(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	openTime := time.Date(2024, 9, 20, 11, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO candles \(symbol, period, openTime, open, high, low, close, volume, quoteVolume, tradeCount\)`).
+		WithArgs("BTC/USDT", "1m", openTime, "10000", "10100", "9900", "10050", "2", "20100", 3).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = pg.SaveCandles([]models.Candle{{
+		Symbol:      "BTC/USDT",
+		Interval:    "1m",
+		OpenTime:    openTime,
+		Open:        "10000",
+		High:        "10100",
+		Low:         "9900",
+		Close:       "10050",
+		Volume:      "2",
+		QuoteVolume: "20100",
+		TradeCount:  3,
+	}})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCandles(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		from = time.Date(2024, 9, 20, 0, 0, 0, 0, time.UTC)
+		to   = from.Add(time.Hour)
+	)
+
+	mock.ExpectQuery(`SELECT symbol, period, openTime, open, high, low, close, volume, quoteVolume, tradeCount FROM candles WHERE symbol = \$1 AND period = \$2 AND openTime >= \$3 AND openTime < \$4 ORDER BY openTime LIMIT \$5`).
+		WithArgs("BTC/USDT", "1m", from, to, 1000).
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "period", "openTime", "open", "high", "low", "close", "volume", "quoteVolume", "tradeCount"}).
+			AddRow("BTC/USDT", "1m", from, "10000", "10100", "9900", "10050", "2", "20100", 3))
+
+	candles, err := pg.GetCandles("BTC/USDT", "1m", from, to, 1000)
+	require.NoError(t, err)
+	require.Len(t, candles, 1)
+	require.Equal(t, "10050", candles[0].Close)
+	require.True(t, candles[0].Closed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLastCandleOpenTimes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		btcOpenTime = time.Date(2024, 9, 20, 11, 0, 0, 0, time.UTC)
+		ethOpenTime = time.Date(2024, 9, 19, 8, 0, 0, 0, time.UTC)
+	)
+
+	mock.ExpectQuery(`SELECT symbol, period, MAX\(openTime\) FROM candles GROUP BY symbol, period`).
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "period", "max"}).
+			AddRow("BTC/USDT", "1m", btcOpenTime).
+			AddRow("ETH/USDT", "1m", ethOpenTime))
+
+	openTimes, err := pg.GetLastCandleOpenTimes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]time.Time{
+		"BTC/USDT": {"1m": btcOpenTime},
+		"ETH/USDT": {"1m": ethOpenTime},
+	}, openTimes)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
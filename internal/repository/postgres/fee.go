@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// SetFeeSchedule replaces the tiers of the symbol, no tiers removes its schedule
+func (p *Postgres) SetFeeSchedule(schedule models.FeeSchedule) error {
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), `
+	DELETE FROM feeTiers WHERE symbol = $1
+	`, schedule.Symbol)
+	if err != nil {
+		p.logger.Error("Error deleting fee tiers", "error", err)
+		return err
+	}
+
+	for _, tier := range schedule.Tiers {
+		_, err = tx.Exec(context.Background(), `
+		INSERT INTO feeTiers (symbol, minVolume, makerRate, takerRate)
+		VALUES ($1, $2, $3, $4)
+		`, schedule.Symbol, tier.MinVolume, tier.MakerRate, tier.TakerRate)
+		if err != nil {
+			p.logger.Error("Error inserting fee tier", "error", err)
+			return err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetFeeSchedules() ([]models.FeeSchedule, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT symbol, minVolume, makerRate, takerRate
+	FROM feeTiers
+	ORDER BY symbol, minVolume
+	`)
+	if err != nil {
+		p.logger.Error("Error selecting fee tiers", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.FeeSchedule
+	for rows.Next() {
+		var (
+			symbol string
+			tier   models.FeeTier
+		)
+		err := rows.Scan(&symbol, &tier.MinVolume, &tier.MakerRate, &tier.TakerRate)
+		if err != nil {
+			p.logger.Error("Error scanning fee tier", "error", err)
+			return nil, err
+		}
+
+		if len(schedules) == 0 || schedules[len(schedules)-1].Symbol != symbol {
+			schedules = append(schedules, models.FeeSchedule{Symbol: symbol})
+		}
+		schedules[len(schedules)-1].Tiers = append(schedules[len(schedules)-1].Tiers, tier)
+	}
+	return schedules, nil
+}
+
+// GetUserVolumes returns the daily quote volume of every user and symbol traded since,
+// both sides of a trade count towards the volume of their owners
+func (p *Postgres) GetUserVolumes(since time.Time) ([]models.UserVolume, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT orders.userID, trades.symbol, date_trunc('day', trades.executedAt) AS day, SUM(trades.price * trades.qty)
+	FROM trades
+	JOIN orders ON orders.id IN (trades.makerOrderID, trades.takerOrderID)
+	WHERE trades.executedAt >= $1
+	GROUP BY orders.userID, trades.symbol, day
+	`, since)
+	if err != nil {
+		p.logger.Error("Error selecting user volumes", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var volumes []models.UserVolume
+	for rows.Next() {
+		var volume models.UserVolume
+		err := rows.Scan(&volume.UserID, &volume.Symbol, &volume.Day, &volume.Volume)
+		if err != nil {
+			p.logger.Error("Error scanning user volume", "error", err)
+			return nil, err
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
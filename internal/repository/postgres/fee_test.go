@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFeeSchedule(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM feeTiers WHERE symbol = \$1`).
+		WithArgs("BTC/USDT").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO feeTiers \(symbol, minVolume, makerRate, takerRate\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs("BTC/USDT", "0", "0.001", "0.002").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO feeTiers \(symbol, minVolume, makerRate, takerRate\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs("BTC/USDT", "1000000", "-0.0001", "0.0008").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = pg.SetFeeSchedule(models.FeeSchedule{
+		Symbol: "BTC/USDT",
+		Tiers: []models.FeeTier{
+			{MinVolume: "0", MakerRate: "0.001", TakerRate: "0.002"},
+			{MinVolume: "1000000", MakerRate: "-0.0001", TakerRate: "0.0008"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFeeSchedules(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT symbol, minVolume, makerRate, takerRate FROM feeTiers ORDER BY symbol, minVolume`).
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "minVolume", "makerRate", "takerRate"}).
+			AddRow("BTC/USDT", "0", "0.001", "0.002").
+			AddRow("BTC/USDT", "1000000", "-0.0001", "0.0008").
+			AddRow("ETH/USDT", "0", "0.001", "0.001"))
+
+	schedules, err := pg.GetFeeSchedules()
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	require.Len(t, schedules[0].Tiers, 2)
+	require.Equal(t, "-0.0001", schedules[0].Tiers[1].MakerRate)
+	require.Equal(t, "ETH/USDT", schedules[1].Symbol)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserVolumes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		since = time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+		day   = time.Date(2024, 9, 20, 0, 0, 0, 0, time.UTC)
+	)
+
+	mock.ExpectQuery(`SELECT orders.userID, trades.symbol, date_trunc\('day', trades.executedAt\) AS day, SUM\(trades.price \* trades.qty\) FROM trades JOIN orders ON orders.id IN \(trades.makerOrderID, trades.takerOrderID\) WHERE trades.executedAt >= \$1 GROUP BY orders.userID, trades.symbol, day`).
+		WithArgs(since).
+		WillReturnRows(pgxmock.NewRows([]string{"userID", "symbol", "day", "sum"}).
+			AddRow(int64(1), "BTC/USDT", day, "25000"))
+
+	volumes, err := pg.GetUserVolumes(since)
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	require.Equal(t, "25000", volumes[0].Volume)
+	require.Equal(t, day, volumes[0].Day)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// tradeEntries returns the balanced entries of a trade. The buyer receives the base
+// asset and pays the trade value plus its fee in the quote asset, the seller gives
+// up the base asset and receives the value less its fee, the fees go to the fee account
+func tradeEntries(trade models.Trade, makerUserID, takerUserID int64) ([]models.LedgerEntry, error) {
+	price, err := decimal.NewFromString(trade.Price)
+	if err != nil {
+		return nil, err
+	}
+	qty, err := decimal.NewFromString(trade.Qty)
+	if err != nil {
+		return nil, err
+	}
+	makerFee, err := decimal.NewFromString(trade.MakerFee)
+	if err != nil {
+		return nil, err
+	}
+	takerFee, err := decimal.NewFromString(trade.TakerFee)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		buyer, seller       = takerUserID, makerUserID
+		buyerFee, sellerFee = takerFee, makerFee
+		value               = price.Mul(qty)
+	)
+	if !trade.IsBid {
+		buyer, seller = seller, buyer
+		buyerFee, sellerFee = sellerFee, buyerFee
+	}
+
+	base, quote, err := models.SplitSymbol(trade.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.LedgerEntry{
+		{TradeID: trade.ID, UserID: buyer, Asset: base, Amount: qty.String()},
+		{TradeID: trade.ID, UserID: seller, Asset: base, Amount: qty.Neg().String()},
+		{TradeID: trade.ID, UserID: buyer, Asset: quote, Amount: value.Add(buyerFee).Neg().String()},
+		{TradeID: trade.ID, UserID: seller, Asset: quote, Amount: value.Sub(sellerFee).String()},
+	}
+	if fees := buyerFee.Add(sellerFee); !fees.IsZero() {
+		entries = append(entries, models.LedgerEntry{TradeID: trade.ID, UserID: models.FeeAccountUserID, Asset: quote, Amount: fees.String()})
+	}
+	return entries, nil
+}
+
+func (p *Postgres) addLedgerEntries(tx pgx.Tx, entries []models.LedgerEntry) error {
+	for _, entry := range entries {
+		_, err := tx.Exec(context.Background(), `
+		INSERT INTO ledgerEntries (tradeID, userID, asset, amount)
+		VALUES ($1, $2, $3, $4)
+		`, entry.TradeID, entry.UserID, entry.Asset, entry.Amount)
+		if err != nil {
+			p.logger.Error("Error inserting ledger entry", "tradeID", entry.TradeID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBalances returns the user's balance per asset, an empty asset matches every asset
+func (p *Postgres) GetBalances(userID int64, asset string) ([]models.Balance, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT asset, SUM(amount)
+	FROM ledgerEntries
+	WHERE userID = $1 AND ($2 = '' OR asset = $2)
+	GROUP BY asset
+	ORDER BY asset
+	`, userID, asset)
+	if err != nil {
+		p.logger.Error("Error selecting balances", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []models.Balance
+	for rows.Next() {
+		var balance = models.Balance{UserID: userID}
+		if err := rows.Scan(&balance.Asset, &balance.Amount); err != nil {
+			p.logger.Error("Error scanning balances", "error", err)
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// GetLedgerImbalances returns the assets whose entries do not sum to zero,
+// trading only moves assets between accounts so a balanced ledger returns none
+func (p *Postgres) GetLedgerImbalances() ([]models.LedgerImbalance, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT asset, SUM(amount)
+	FROM ledgerEntries
+	GROUP BY asset
+	HAVING SUM(amount) <> 0
+	ORDER BY asset
+	`)
+	if err != nil {
+		p.logger.Error("Error selecting ledger imbalances", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imbalances []models.LedgerImbalance
+	for rows.Next() {
+		var imbalance models.LedgerImbalance
+		if err := rows.Scan(&imbalance.Asset, &imbalance.Sum); err != nil {
+			p.logger.Error("Error scanning ledger imbalances", "error", err)
+			return nil, err
+		}
+		imbalances = append(imbalances, imbalance)
+	}
+	return imbalances, nil
+}
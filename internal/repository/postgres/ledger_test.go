@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalances(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT asset, SUM\(amount\) FROM ledgerEntries WHERE userID = \$1 AND \(\$2 = '' OR asset = \$2\) GROUP BY asset ORDER BY asset`).
+		WithArgs(int64(1), "").
+		WillReturnRows(pgxmock.NewRows([]string{"asset", "sum"}).
+			AddRow("BTC", "0.5").
+			AddRow("USDT", "-5002.5"))
+
+	balances, err := pg.GetBalances(1, "")
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.Equal(t, int64(1), balances[0].UserID)
+	require.Equal(t, "BTC", balances[0].Asset)
+	require.Equal(t, "-5002.5", balances[1].Amount)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLedgerImbalances(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT asset, SUM\(amount\) FROM ledgerEntries GROUP BY asset HAVING SUM\(amount\) <> 0 ORDER BY asset`).
+		WillReturnRows(pgxmock.NewRows([]string{"asset", "sum"}).AddRow("USDT", "0.1"))
+
+	imbalances, err := pg.GetLedgerImbalances()
+	require.NoError(t, err)
+	require.Len(t, imbalances, 1)
+	require.Equal(t, "USDT", imbalances[0].Asset)
+	require.Equal(t, "0.1", imbalances[0].Sum)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
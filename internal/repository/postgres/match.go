@@ -2,16 +2,19 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/jackc/pgx/v5"
 )
 
-func (p *Postgres) AddMatches(matches repository.AddMatchesReq) ([]models.Order, error) {
+func (p *Postgres) AddMatches(matches repository.AddMatchesReq) ([]models.Order, []models.Trade, error) {
 	tx, err := p.db.Begin(context.Background())
 	if err != nil {
 		p.logger.Error("Error creating transaction", "error", err)
-		return nil, err
+		return nil, nil, err
 	}
 	defer tx.Rollback(context.Background())
 
@@ -21,11 +24,11 @@ func (p *Postgres) AddMatches(matches repository.AddMatchesReq) ([]models.Order,
 			UPDATE orders
 			SET sizeFilled = $1
 			WHERE id = $2
-			RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt
+			RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
 		`, sizeFilled, orderID).Scan(
 			&order.ID, &order.UserID, &order.IsBid, &order.Symbol, &order.Price,
 			&order.Qty, &order.SizeFilled, &order.Status, &order.Type,
-			&order.CreatedAt, &order.ClosedAt,
+			&order.CreatedAt, &order.ClosedAt, &order.ClientOrderID,
 		)
 		if err != nil {
 			return models.Order{}, err
@@ -36,48 +39,152 @@ func (p *Postgres) AddMatches(matches repository.AddMatchesReq) ([]models.Order,
 	updatedOrder, err := updateOrder(matches.OrderSizeFilled, matches.OrderID)
 	if err != nil {
 		p.logger.Error("Error updating sizeFilled", "error", err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	var updatedOrders = []models.Order{updatedOrder}
+	var (
+		updatedOrders = []models.Order{updatedOrder}
+		trades        []models.Trade
+		symbol        = updatedOrder.Symbol
+		isBid         = updatedOrder.IsBid
+	)
 
 	for _, match := range matches.Matches {
 		updatedOrder, err = updateOrder(match.CounterOrderSizeFilled, match.CounterOrderID)
 		if err != nil {
 			p.logger.Error("Error updating sizeFilled", "error", err)
-			return nil, err
+			return nil, nil, err
 		}
 		updatedOrders = append(updatedOrders, updatedOrder)
 
-		_, err = tx.Exec(context.Background(), `
-			INSERT INTO matches (orderID, orderIDCounter, qty, price)
-			VALUES ($1, $2, $3, $4)
-		`, matches.OrderID, match.CounterOrderID, match.Qty, match.Price)
+		var trade = models.Trade{
+			Symbol:       symbol,
+			Price:        match.Price,
+			Qty:          match.Qty,
+			IsBid:        isBid,
+			MakerOrderID: match.CounterOrderID,
+			TakerOrderID: matches.OrderID,
+			MakerFee:     match.MakerFee,
+			TakerFee:     match.TakerFee,
+			FeeCurrency:  match.FeeCurrency,
+		}
+		err = tx.QueryRow(context.Background(), `
+			INSERT INTO trades (symbol, makerOrderID, takerOrderID, isBid, price, qty, makerFee, takerFee, feeCurrency)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, executedAt
+		`, symbol, match.CounterOrderID, matches.OrderID, isBid, match.Price, match.Qty,
+			match.MakerFee, match.TakerFee, match.FeeCurrency,
+		).Scan(&trade.ID, &trade.ExecutedAt)
+		if err != nil {
+			p.logger.Error("Error inserting trade", "error", err)
+			return nil, nil, err
+		}
+		trades = append(trades, trade)
+
+		entries, err := tradeEntries(trade, updatedOrder.UserID, updatedOrders[0].UserID)
 		if err != nil {
-			p.logger.Error("Error inserting matches", "error", err)
-			return nil, err
+			p.logger.Error("Error building ledger entries", "tradeID", trade.ID, "error", err)
+			return nil, nil, err
+		}
+		if err := p.addLedgerEntries(tx, entries); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, order := range updatedOrders {
+		if err := p.addOrderEvent(tx, order, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, trade := range trades {
+		if err := p.addTradeEvent(tx, trade); err != nil {
+			return nil, nil, err
 		}
 	}
 
 	err = tx.Commit(context.Background())
 	if err != nil {
 		p.logger.Error("Error commiting transaction", "error", err)
+		return nil, nil, err
+	}
+
+	return updatedOrders, trades, nil
+}
+
+func (p *Postgres) GetTrades(symbol string, afterTradeID int64, limit int) ([]models.Trade, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT id, symbol, price, qty, isBid, makerOrderID, takerOrderID, makerFee, takerFee, feeCurrency, executedAt
+	FROM trades
+	WHERE ($1 = '' OR symbol = $1) AND id > $2
+	ORDER BY id
+	LIMIT $3
+	`, symbol, afterTradeID, limit)
+	if err != nil {
+		p.logger.Error("Error selecting trades", "error", err)
 		return nil, err
 	}
+	return p.scanTrades(rows)
+}
 
-	return updatedOrders, nil
+func (p *Postgres) GetTradesSince(since time.Time, afterTradeID int64, limit int) ([]models.Trade, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT id, symbol, price, qty, isBid, makerOrderID, takerOrderID, makerFee, takerFee, feeCurrency, executedAt
+	FROM trades
+	WHERE executedAt >= $1 AND id > $2
+	ORDER BY id
+	LIMIT $3
+	`, since, afterTradeID, limit)
+	if err != nil {
+		p.logger.Error("Error selecting trades", "error", err)
+		return nil, err
+	}
+	return p.scanTrades(rows)
 }
 
+func (p *Postgres) scanTrades(rows pgx.Rows) ([]models.Trade, error) {
+	defer rows.Close()
+
+	var trades []models.Trade
+	for rows.Next() {
+		var trade models.Trade
+		err := rows.Scan(
+			&trade.ID,
+			&trade.Symbol,
+			&trade.Price,
+			&trade.Qty,
+			&trade.IsBid,
+			&trade.MakerOrderID,
+			&trade.TakerOrderID,
+			&trade.MakerFee,
+			&trade.TakerFee,
+			&trade.FeeCurrency,
+			&trade.ExecutedAt,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning trades", "error", err)
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// GetMatches returns the fills of the order, whichever side of the trade it was on
 func (p *Postgres) GetMatches(orderID int64) ([]models.Match, error) {
 	rows, err := p.db.Query(context.Background(), `
-	SELECT FROM matches(qty, price) WHERE orderID = $1
+	SELECT qty, price
+	FROM trades
+	WHERE makerOrderID = $1 OR takerOrderID = $1
+	ORDER BY id
 	`, orderID)
 	if err != nil {
 		p.logger.Error("Error selecting matches", "error", err)
 		return nil, err
 	}
+	defer rows.Close()
 
-	var mathces []models.Match
+	var matches []models.Match
 	for rows.Next() {
 		var match models.Match
 		err := rows.Scan(&match.Qty, &match.Price)
@@ -85,7 +192,91 @@ func (p *Postgres) GetMatches(orderID int64) ([]models.Match, error) {
 			p.logger.Error("Error scanning matches", "error", err)
 			return nil, err
 		}
-		mathces = append(mathces, match)
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// fillsQuery selects trades joined with the order of the fill, whichever side of the trade it was on
+const fillsQuery = `
+	SELECT t.id, o.id, o.userID,
+		CASE WHEN t.makerOrderID = o.id THEN t.takerOrderID ELSE t.makerOrderID END,
+		t.symbol, o.isBid, t.price, t.qty,
+		CASE WHEN t.makerOrderID = o.id THEN 'maker' ELSE 'taker' END,
+		CASE WHEN t.makerOrderID = o.id THEN t.makerFee ELSE t.takerFee END,
+		t.feeCurrency, t.executedAt
+	FROM trades t
+	JOIN orders o ON o.id = t.makerOrderID OR o.id = t.takerOrderID`
+
+// GetOrderFills returns the fills of the order, oldest first
+func (p *Postgres) GetOrderFills(orderID int64) ([]models.Fill, error) {
+	rows, err := p.db.Query(context.Background(), fillsQuery+`
+	WHERE o.id = $1
+	ORDER BY t.id
+	`, orderID)
+	if err != nil {
+		p.logger.Error("Error selecting fills", "error", err)
+		return nil, err
+	}
+	return p.scanFills(rows)
+}
+
+// GetUserFills returns up to filter.Limit fills of filter.UserID, newest first
+func (p *Postgres) GetUserFills(filter models.FillsFilter) ([]models.Fill, error) {
+	var (
+		query = fillsQuery + `
+	WHERE o.userID = $1`
+		args = []any{filter.UserID}
+	)
+
+	if filter.Symbol != "" {
+		args = append(args, filter.Symbol)
+		query += fmt.Sprintf(" AND o.symbol = $%d", len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(" AND t.executedAt >= $%d", len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(" AND t.executedAt < $%d", len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY t.executedAt DESC, t.id DESC LIMIT $%d", len(args))
+
+	rows, err := p.db.Query(context.Background(), query, args...)
+	if err != nil {
+		p.logger.Error("Error selecting fills", "error", err)
+		return nil, err
+	}
+	return p.scanFills(rows)
+}
+
+func (p *Postgres) scanFills(rows pgx.Rows) ([]models.Fill, error) {
+	defer rows.Close()
+
+	var fills []models.Fill
+	for rows.Next() {
+		var fill models.Fill
+		err := rows.Scan(
+			&fill.TradeID,
+			&fill.OrderID,
+			&fill.UserID,
+			&fill.CounterOrderID,
+			&fill.Symbol,
+			&fill.IsBid,
+			&fill.Price,
+			&fill.Qty,
+			&fill.Liquidity,
+			&fill.Fee,
+			&fill.FeeCurrency,
+			&fill.ExecutedAt,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning fills", "error", err)
+			return nil, err
+		}
+		fills = append(fills, fill)
 	}
-	return mathces, nil
+	return fills, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/require"
@@ -25,7 +26,7 @@ func TestAddMatches(t *testing.T) {
 		OrderID:         1,
 		OrderSizeFilled: "0.5",
 		Matches: []repository.Match{
-			{CounterOrderID: 2, Qty: "0.5", Price: "10000"},
+			{CounterOrderID: 2, Qty: "0.5", Price: "10000", CounterOrderSizeFilled: "0.5", MakerFee: "-0.5", TakerFee: "2.5", FeeCurrency: "USDT"},
 		},
 	}
 
@@ -33,29 +34,60 @@ func TestAddMatches(t *testing.T) {
 	mock.ExpectBegin()
 
 	// Expectations for updating order
-	mock.ExpectQuery(`UPDATE orders SET sizeFilled = \$1 WHERE id = \$2 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt`).
+	mock.ExpectQuery(`UPDATE orders SET sizeFilled = \$1 WHERE id = \$2 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID`).
 		WithArgs("0.5", int64(1)).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt"}).
-			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0.5", "filling", "limit", time.Now(), nil))
-
-	mock.ExpectQuery(`UPDATE orders SET sizeFilled = \$1 WHERE id = \$2 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt`).
-		WithArgs("0.5", int64(1)).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt"}).
-			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0.5", "filling", "limit", time.Now(), nil))
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0.5", "filling", "limit", time.Now(), nil, ""))
+
+	mock.ExpectQuery(`UPDATE orders SET sizeFilled = \$1 WHERE id = \$2 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID`).
+		WithArgs("0.5", int64(2)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(2), int64(2), false, "BTC/USDT", "10000", "1", "0.5", "filling", "limit", time.Now(), nil, ""))
+
+	// Expectations for inserting trade
+	mock.ExpectQuery(`INSERT INTO trades \(symbol, makerOrderID, takerOrderID, isBid, price, qty, makerFee, takerFee, feeCurrency\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) RETURNING id, executedAt`).
+		WithArgs("BTC/USDT", int64(2), int64(1), true, "10000", "0.5", "-0.5", "2.5", "USDT").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "executedAt"}).AddRow(int64(7), time.Now()))
+
+	// Expectations for the ledger, the taker bought so it pays the value and its fee
+	for _, entry := range [][]any{
+		{int64(7), int64(1), "BTC", "0.5"},
+		{int64(7), int64(2), "BTC", "-0.5"},
+		{int64(7), int64(1), "USDT", "-5002.5"},
+		{int64(7), int64(2), "USDT", "5000.5"},
+		{int64(7), int64(0), "USDT", "2"},
+	} {
+		mock.ExpectExec(`INSERT INTO ledgerEntries \(tradeID, userID, asset, amount\) VALUES \(\$1, \$2, \$3, \$4\)`).
+			WithArgs(entry...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
 
-	// Expectations for inserting match
-	mock.ExpectExec(`INSERT INTO matches \(orderID, orderIDCounter, qty, price\) VALUES \(\$1, \$2, \$3, \$4\)`).
-		WithArgs(int64(1), int64(2), "0.5", "10000").
+	// Expectations for the outbox, both orders then the trade
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs("orders", "1", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs("orders", "2", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs("trades", "BTC/USDT", pgxmock.AnyArg()).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	// Expectations for transaction commit
 	mock.ExpectCommit()
 
 	// Call the method
-	orders, err := pg.AddMatches(matchesReq)
+	orders, trades, err := pg.AddMatches(matchesReq)
 	require.NoError(t, err)
 	require.Len(t, orders, 2)
 	require.Equal(t, "BTC/USDT", orders[0].Symbol)
+	require.Len(t, trades, 1)
+	require.Equal(t, int64(7), trades[0].ID)
+	require.True(t, trades[0].IsBid)
+	require.Equal(t, int64(2), trades[0].MakerOrderID)
+	require.Equal(t, int64(1), trades[0].TakerOrderID)
+	require.Equal(t, "-0.5", trades[0].MakerFee)
+	require.Equal(t, "USDT", trades[0].FeeCurrency)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -70,7 +102,7 @@ func TestGetMatches(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectQuery(`SELECT FROM matches\(qty, price\) WHERE orderID = \$1`).
+	mock.ExpectQuery(`SELECT qty, price FROM trades WHERE makerOrderID = \$1 OR takerOrderID = \$1 ORDER BY id`).
 		WithArgs(int64(1)).
 		WillReturnRows(pgxmock.NewRows([]string{"qty", "price"}).
 			AddRow("0.5", "10000").
@@ -84,3 +116,85 @@ func TestGetMatches(t *testing.T) {
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetTrades(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT id, symbol, price, qty, isBid, makerOrderID, takerOrderID, makerFee, takerFee, feeCurrency, executedAt FROM trades WHERE \(\$1 = '' OR symbol = \$1\) AND id > \$2 ORDER BY id LIMIT \$3`).
+		WithArgs("BTC/USDT", int64(10), 100).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "symbol", "price", "qty", "isBid", "makerOrderID", "takerOrderID", "makerFee", "takerFee", "feeCurrency", "executedAt"}).
+			AddRow(int64(11), "BTC/USDT", "10000", "0.5", true, int64(1), int64(3), "0", "0", "USDT", time.Now()).
+			AddRow(int64(12), "BTC/USDT", "10010", "0.1", false, int64(2), int64(4), "0", "0", "USDT", time.Now()))
+
+	trades, err := pg.GetTrades("BTC/USDT", 10, 100)
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	require.Equal(t, int64(11), trades[0].ID)
+	require.Equal(t, "10010", trades[1].Price)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+var fillColumns = []string{"id", "id", "userID", "counterOrderID", "symbol", "isBid", "price", "qty", "liquidity", "fee", "feeCurrency", "executedAt"}
+
+func TestGetOrderFills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`FROM trades t JOIN orders o ON o.id = t.makerOrderID OR o.id = t.takerOrderID WHERE o.id = \$1 ORDER BY t.id`).
+		WithArgs(int64(1)).
+		WillReturnRows(pgxmock.NewRows(fillColumns).
+			AddRow(int64(11), int64(1), int64(5), int64(3), "BTC/USDT", true, "10000", "0.5", "maker", "-0.5", "USDT", time.Now()))
+
+	fills, err := pg.GetOrderFills(1)
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	require.Equal(t, int64(3), fills[0].CounterOrderID)
+	require.Equal(t, "maker", fills[0].Liquidity)
+	require.Equal(t, "-0.5", fills[0].Fee)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserFills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		from = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to   = from.Add(24 * time.Hour)
+	)
+
+	mock.ExpectQuery(`WHERE o.userID = \$1 AND o.symbol = \$2 AND t.executedAt >= \$3 AND t.executedAt < \$4 ORDER BY t.executedAt DESC, t.id DESC LIMIT \$5`).
+		WithArgs(int64(5), "BTC/USDT", from, to, 100).
+		WillReturnRows(pgxmock.NewRows(fillColumns).
+			AddRow(int64(12), int64(4), int64(5), int64(2), "BTC/USDT", false, "10010", "0.1", "taker", "2.5", "USDT", from.Add(time.Hour)).
+			AddRow(int64(11), int64(1), int64(5), int64(3), "BTC/USDT", true, "10000", "0.5", "maker", "-0.5", "USDT", from))
+
+	fills, err := pg.GetUserFills(models.FillsFilter{UserID: 5, Symbol: "BTC/USDT", From: from, To: to, Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, fills, 2)
+	require.Equal(t, int64(12), fills[0].TradeID)
+	require.False(t, fills[0].IsBid)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
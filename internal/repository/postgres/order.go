@@ -2,31 +2,89 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func (p *Postgres) CreateOrder(order models.PlaceOrderReq) (int64, error) {
-	var orderID int
+const (
+	uniqueViolation    = "23505"
+	clientOrderIDIndex = "orders_user_client_order_id_idx"
+)
+
+// NextOrderID takes an ID from the orders sequence, IDs taken by orders that are
+// never created are skipped
+func (p *Postgres) NextOrderID() (int64, error) {
+	var orderID int64
 	err := p.db.QueryRow(context.Background(), `
+	SELECT nextval(pg_get_serial_sequence('orders', 'id'))
+	`).Scan(&orderID)
+	if err != nil {
+		p.logger.Error("Error taking order ID", "error", err)
+		return 0, err
+	}
+	return orderID, nil
+}
+
+// CreateOrder fails with "Duplicate client order ID" when the user already placed
+// an order with order.ClientOrderID, the unique index decides between concurrent retries
+func (p *Postgres) CreateOrder(orderID int64, req models.PlaceOrderReq) error {
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var order models.Order
+	err = tx.QueryRow(context.Background(), `
 	INSERT INTO orders
-	(userID, isBid, symbol, price, qty, type, status)
+	(id, userID, isBid, symbol, price, qty, type, status, clientOrderID)
 	VALUES
-	($1, $2, $3, $4, $5, $6, $7)
-	RETURNING
-	id
-	`, order.UserID, order.IsBid, order.Symbol, order.Price, order.Qty, order.Type, "filling").Scan(&orderID)
+	($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	`, orderID, req.UserID, req.IsBid, req.Symbol, req.Price, req.Qty, req.Type, "filling", req.ClientOrderID).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.IsBid,
+		&order.Symbol,
+		&order.Price,
+		&order.Qty,
+		&order.SizeFilled,
+		&order.Status,
+		&order.Type,
+		&order.CreatedAt,
+		&order.ClosedAt,
+		&order.ClientOrderID,
+	)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == clientOrderIDIndex {
+			return errors.New("Duplicate client order ID")
+		}
 		p.logger.Error("Error inserting order", "error", err)
-		return 0, err
+		return err
 	}
-	return int64(orderID), nil
+
+	if err := p.addOrderEvent(tx, order, ""); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return err
+	}
+	return nil
 }
 
 func (p *Postgres) GetOrderByOrderID(orderID int64) (models.Order, error) {
 	var order models.Order
 	row := p.db.QueryRow(context.Background(), `
-	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt
+	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
 	FROM orders
 	WHERE id = $1
 	`, orderID)
@@ -43,20 +101,92 @@ func (p *Postgres) GetOrderByOrderID(orderID int64) (models.Order, error) {
 		&order.Type,
 		&order.CreatedAt,
 		&order.ClosedAt,
+		&order.ClientOrderID,
 	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Order{}, errors.New("Order not found")
+		}
 		p.logger.Error("Error scanning order", "error", err)
 		return models.Order{}, err
 	}
 	return order, nil
 }
 
-func (p *Postgres) GetOrdersByUser(userID int64) ([]models.Order, error) {
-	rows, err := p.db.Query(context.Background(), `
-	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt
+func (p *Postgres) GetOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error) {
+	var order models.Order
+	row := p.db.QueryRow(context.Background(), `
+	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
 	FROM orders
-	WHERE userID = $1
-	`, userID)
+	WHERE userID = $1 AND clientOrderID = $2
+	`, userID, clientOrderID)
+
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.IsBid,
+		&order.Symbol,
+		&order.Price,
+		&order.Qty,
+		&order.SizeFilled,
+		&order.Status,
+		&order.Type,
+		&order.CreatedAt,
+		&order.ClosedAt,
+		&order.ClientOrderID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Order{}, errors.New("Order not found")
+		}
+		p.logger.Error("Error scanning order", "error", err)
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+// GetOrdersByUser returns up to filter.Limit orders of filter.UserID, newest first
+func (p *Postgres) GetOrdersByUser(filter models.OrdersFilter) ([]models.Order, error) {
+	var (
+		query = `
+	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	FROM orders
+	WHERE userID = $1`
+		args = []any{filter.UserID}
+	)
+
+	if filter.Symbol != "" {
+		args = append(args, filter.Symbol)
+		query += fmt.Sprintf(" AND symbol = $%d", len(args))
+	}
+	if filter.IsBid != nil {
+		args = append(args, *filter.IsBid)
+		query += fmt.Sprintf(" AND isBid = $%d", len(args))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		query += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		args = append(args, filter.CreatedFrom)
+		query += fmt.Sprintf(" AND createdAt >= $%d", len(args))
+	}
+	if !filter.CreatedTo.IsZero() {
+		args = append(args, filter.CreatedTo)
+		query += fmt.Sprintf(" AND createdAt < $%d", len(args))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		query += fmt.Sprintf(" AND (createdAt, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY createdAt DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := p.db.Query(context.Background(), query, args...)
 	if err != nil {
 		p.logger.Error("Error selecting orders", "error", err)
 		return nil, err
@@ -78,6 +208,7 @@ func (p *Postgres) GetOrdersByUser(userID int64) ([]models.Order, error) {
 			&order.Type,
 			&order.CreatedAt,
 			&order.ClosedAt,
+			&order.ClientOrderID,
 		)
 		if err != nil {
 			p.logger.Error("Error scanning order", "error", err)
@@ -90,7 +221,7 @@ func (p *Postgres) GetOrdersByUser(userID int64) ([]models.Order, error) {
 
 func (p *Postgres) GetNotFilledOrdersByUser(userID int64) ([]models.Order, error) {
 	rows, err := p.db.Query(context.Background(), `
-	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt
+	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
 	FROM orders
 	WHERE userID = $1 AND status IN ('filling', 'canceled')
 	`, userID)
@@ -115,6 +246,63 @@ func (p *Postgres) GetNotFilledOrdersByUser(userID int64) ([]models.Order, error
 			&order.Type,
 			&order.CreatedAt,
 			&order.ClosedAt,
+			&order.ClientOrderID,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning order", "error", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func (p *Postgres) GetOpenOrders(filter models.OpenOrdersFilter) ([]models.Order, error) {
+	var (
+		query = `
+	SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	FROM orders
+	WHERE status = 'filling' AND type = 'limit'`
+		args []any
+	)
+
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		query += fmt.Sprintf(" AND userID = $%d", len(args))
+	}
+	if filter.Symbol != "" {
+		args = append(args, filter.Symbol)
+		query += fmt.Sprintf(" AND symbol = $%d", len(args))
+	}
+	if filter.IsBid != nil {
+		args = append(args, *filter.IsBid)
+		query += fmt.Sprintf(" AND isBid = $%d", len(args))
+	}
+	query += " ORDER BY createdAt, id"
+
+	rows, err := p.db.Query(context.Background(), query, args...)
+	if err != nil {
+		p.logger.Error("Error selecting orders", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.IsBid,
+			&order.Symbol,
+			&order.Price,
+			&order.Qty,
+			&order.SizeFilled,
+			&order.Status,
+			&order.Type,
+			&order.CreatedAt,
+			&order.ClosedAt,
+			&order.ClientOrderID,
 		)
 		if err != nil {
 			p.logger.Error("Error scanning order", "error", err)
@@ -126,25 +314,161 @@ func (p *Postgres) GetNotFilledOrdersByUser(userID int64) ([]models.Order, error
 }
 
 func (p *Postgres) SetOrderStatusToCancel(orderID int64) error {
-	_, err := p.db.Exec(context.Background(), `
-	UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP 
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var order models.Order
+	err = tx.QueryRow(context.Background(), `
+	UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP
 	WHERE id = $1
-	`, orderID)
+	RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	`, orderID).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.IsBid,
+		&order.Symbol,
+		&order.Price,
+		&order.Qty,
+		&order.SizeFilled,
+		&order.Status,
+		&order.Type,
+		&order.CreatedAt,
+		&order.ClosedAt,
+		&order.ClientOrderID,
+	)
 	if err != nil {
 		p.logger.Error("Error updating order", "error", err)
 		return err
 	}
+
+	if err := p.addOrderEvent(tx, order, ""); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return err
+	}
 	return nil
 }
 
+func (p *Postgres) SetOrdersStatusToCancel(orderIDs []int64, reason string) ([]models.Order, error) {
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	orders, err := p.cancelOrders(tx, orderIDs, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// cancelOrders cancels the orders still filling within tx and adds their events to the outbox
+func (p *Postgres) cancelOrders(tx pgx.Tx, orderIDs []int64, reason string) ([]models.Order, error) {
+	rows, err := tx.Query(context.Background(), `
+	UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP, cancelReason = NULLIF($2, '')
+	WHERE id = ANY($1) AND status = 'filling'
+	RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	`, orderIDs, reason)
+	if err != nil {
+		p.logger.Error("Error updating orders", "error", err)
+		return nil, err
+	}
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.IsBid,
+			&order.Symbol,
+			&order.Price,
+			&order.Qty,
+			&order.SizeFilled,
+			&order.Status,
+			&order.Type,
+			&order.CreatedAt,
+			&order.ClosedAt,
+			&order.ClientOrderID,
+		)
+		if err != nil {
+			rows.Close()
+			p.logger.Error("Error scanning order", "error", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		p.logger.Error("Error updating orders", "error", err)
+		return nil, err
+	}
+
+	for _, order := range orders {
+		if err := p.addOrderEvent(tx, order, reason); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
 func (p *Postgres) SetOrderStatusToError(orderID int64) error {
-	_, err := p.db.Exec(context.Background(), `
-	UPDATE orders SET status = 'error', closedAt = CURRENT_TIMESTAMP 
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var order models.Order
+	err = tx.QueryRow(context.Background(), `
+	UPDATE orders SET status = 'error', closedAt = CURRENT_TIMESTAMP
 	WHERE id = $1
-	`, orderID)
+	RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID
+	`, orderID).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.IsBid,
+		&order.Symbol,
+		&order.Price,
+		&order.Qty,
+		&order.SizeFilled,
+		&order.Status,
+		&order.Type,
+		&order.CreatedAt,
+		&order.ClosedAt,
+		&order.ClientOrderID,
+	)
 	if err != nil {
 		p.logger.Error("Error updating order", "error", err)
 		return err
 	}
+
+	if err := p.addOrderEvent(tx, order, ""); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return err
+	}
 	return nil
 }
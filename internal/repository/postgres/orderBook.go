@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *Postgres) CreateOrderBook(orderBook models.OrderBook) (models.OrderBook, error) {
+	var created models.OrderBook
+	err := p.db.QueryRow(context.Background(), `
+	INSERT INTO orderBooks
+	(symbol, status, tickSize, lotSize, minQty)
+	VALUES
+	($1, 'active', $2, $3, $4)
+	ON CONFLICT (symbol) DO UPDATE SET
+	status = 'active', tickSize = EXCLUDED.tickSize, lotSize = EXCLUDED.lotSize, minQty = EXCLUDED.minQty,
+	createdAt = CURRENT_TIMESTAMP, deletedAt = NULL,
+	delistAt = NULL, delistCancelOrders = FALSE, delistReason = ''
+	WHERE orderBooks.status = 'deleted'
+	RETURNING symbol, status, tickSize, lotSize, minQty, createdAt, deletedAt, delistAt, delistCancelOrders, delistReason
+	`, orderBook.Symbol, orderBook.TickSize, orderBook.LotSize, orderBook.MinQty).Scan(
+		&created.Symbol,
+		&created.Status,
+		&created.TickSize,
+		&created.LotSize,
+		&created.MinQty,
+		&created.CreatedAt,
+		&created.DeletedAt,
+		&created.DelistAt,
+		&created.DelistCancelOrders,
+		&created.DelistReason,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.OrderBook{}, errors.New("Order book already exists")
+		}
+		p.logger.Error("Error inserting order book", "error", err)
+		return models.OrderBook{}, err
+	}
+	return created, nil
+}
+
+func (p *Postgres) DeleteOrderBook(symbol string, orderIDs []int64, reason string) ([]models.Order, error) {
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		p.logger.Error("Error creating transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(), `
+	UPDATE orderBooks
+	SET status = 'deleted', deletedAt = CURRENT_TIMESTAMP
+	WHERE symbol = $1 AND status = 'active'
+	`, symbol)
+	if err != nil {
+		p.logger.Error("Error updating order book status", "error", err)
+		return nil, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return nil, errors.New("Order book not found")
+	}
+
+	var orders []models.Order
+	if len(orderIDs) > 0 {
+		orders, err = p.cancelOrders(tx, orderIDs, reason)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		p.logger.Error("Error commiting transaction", "error", err)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (p *Postgres) ScheduleOrderBookDeletion(req models.DeleteOrderBookReq) error {
+	tag, err := p.db.Exec(context.Background(), `
+	UPDATE orderBooks
+	SET delistAt = $2, delistCancelOrders = $3, delistReason = $4
+	WHERE symbol = $1 AND status = 'active'
+	`, req.Symbol, req.ScheduledAt, req.CancelOrders, req.Reason)
+	if err != nil {
+		p.logger.Error("Error scheduling order book deletion", "error", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("Order book not found")
+	}
+	return nil
+}
+
+func (p *Postgres) GetOrderBooks(includeDeleted bool) ([]models.OrderBook, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT symbol, status, tickSize, lotSize, minQty, createdAt, deletedAt, delistAt, delistCancelOrders, delistReason
+	FROM orderBooks
+	WHERE $1 OR status = 'active'
+	ORDER BY symbol
+	`, includeDeleted)
+	if err != nil {
+		p.logger.Error("Error selecting order books", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderBooks []models.OrderBook
+	for rows.Next() {
+		var orderBook models.OrderBook
+		err := rows.Scan(
+			&orderBook.Symbol,
+			&orderBook.Status,
+			&orderBook.TickSize,
+			&orderBook.LotSize,
+			&orderBook.MinQty,
+			&orderBook.CreatedAt,
+			&orderBook.DeletedAt,
+			&orderBook.DelistAt,
+			&orderBook.DelistCancelOrders,
+			&orderBook.DelistReason,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning order book", "error", err)
+			return nil, err
+		}
+		orderBooks = append(orderBooks, orderBook)
+	}
+	return orderBooks, nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderBook(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	createdAt := time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`INSERT INTO orderBooks.*ON CONFLICT \(symbol\) DO UPDATE.*WHERE orderBooks.status = 'deleted'.*RETURNING symbol, status, tickSize, lotSize, minQty, createdAt, deletedAt, delistAt, delistCancelOrders, delistReason`).
+		WithArgs("BTC/USDT", "0.01", "0.0001", "0.001").
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "status", "tickSize", "lotSize", "minQty", "createdAt", "deletedAt", "delistAt", "delistCancelOrders", "delistReason"}).
+			AddRow("BTC/USDT", "active", "0.01", "0.0001", "0.001", createdAt, nil, nil, false, ""))
+
+	orderBook, err := pg.CreateOrderBook(models.OrderBook{
+		Symbol:   "BTC/USDT",
+		TickSize: "0.01",
+		LotSize:  "0.0001",
+		MinQty:   "0.001",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "active", orderBook.Status)
+	require.Equal(t, createdAt, orderBook.CreatedAt)
+	require.False(t, orderBook.DeletedAt.Valid)
+
+	// an active book with the same symbol makes the upsert return nothing
+	mock.ExpectQuery(`INSERT INTO orderBooks`).
+		WithArgs("BTC/USDT", "0", "0", "0").
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "status", "tickSize", "lotSize", "minQty", "createdAt", "deletedAt", "delistAt", "delistCancelOrders", "delistReason"}))
+
+	_, err = pg.CreateOrderBook(models.OrderBook{Symbol: "BTC/USDT", TickSize: "0", LotSize: "0", MinQty: "0"})
+	require.EqualError(t, err, "Order book already exists")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOrderBook(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE orderBooks SET status = 'deleted', deletedAt = CURRENT_TIMESTAMP WHERE symbol = \$1 AND status = 'active'`).
+		WithArgs("BTC/USDT").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`UPDATE orders SET status = 'canceled'`).
+		WithArgs([]int64{1}, "order book deleted").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "canceled", "limit", time.Now(), time.Now(), ""))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "1", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	orders, err := pg.DeleteOrderBook("BTC/USDT", []int64{1}, "order book deleted")
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, "canceled", orders[0].Status)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE orderBooks`).
+		WithArgs("ETH/USDT").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectRollback()
+
+	_, err = pg.DeleteOrderBook("ETH/USDT", nil, "")
+	require.EqualError(t, err, "Order book not found")
+
+	// the book stays active when its orders cannot be canceled
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE orderBooks`).
+		WithArgs("SOL/USDT").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`UPDATE orders SET status = 'canceled'`).
+		WithArgs([]int64{2}, "order book deleted").
+		WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	_, err = pg.DeleteOrderBook("SOL/USDT", []int64{2}, "order book deleted")
+	require.EqualError(t, err, "connection lost")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduleOrderBookDeletion(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	scheduledAt := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`UPDATE orderBooks SET delistAt = \$2, delistCancelOrders = \$3, delistReason = \$4 WHERE symbol = \$1 AND status = 'active'`).
+		WithArgs("BTC/USDT", scheduledAt, true, "migrating to BTC/USDC").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = pg.ScheduleOrderBookDeletion(models.DeleteOrderBookReq{
+		Symbol:       "BTC/USDT",
+		CancelOrders: true,
+		Reason:       "migrating to BTC/USDC",
+		ScheduledAt:  scheduledAt,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrderBooks(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		createdAt = time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+		deletedAt = createdAt.Add(time.Hour)
+	)
+
+	mock.ExpectQuery(`SELECT symbol, status, tickSize, lotSize, minQty, createdAt, deletedAt, delistAt, delistCancelOrders, delistReason FROM orderBooks WHERE \$1 OR status = 'active' ORDER BY symbol`).
+		WithArgs(true).
+		WillReturnRows(pgxmock.NewRows([]string{"symbol", "status", "tickSize", "lotSize", "minQty", "createdAt", "deletedAt", "delistAt", "delistCancelOrders", "delistReason"}).
+			AddRow("BTC/USDT", "active", "0.01", "0.0001", "0.001", createdAt, nil, deletedAt, true, "migrating to BTC/USDC").
+			AddRow("ETH/USDT", "deleted", "0", "0", "0", createdAt, deletedAt, nil, false, ""))
+
+	orderBooks, err := pg.GetOrderBooks(true)
+	require.NoError(t, err)
+	require.Len(t, orderBooks, 2)
+	require.Equal(t, "BTC/USDT", orderBooks[0].Symbol)
+	require.True(t, orderBooks[0].DelistAt.Valid)
+	require.Equal(t, "migrating to BTC/USDC", orderBooks[0].DelistReason)
+	require.True(t, orderBooks[1].DeletedAt.Valid)
+	require.Equal(t, deletedAt, orderBooks[1].DeletedAt.Time)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/require"
 )
@@ -23,20 +24,66 @@ func TestCreateOrder(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectQuery(`INSERT INTO orders.*RETURNING id`).
-		WithArgs(int64(1), true, "BTC/USDT", "10000", "1", "limit", "filling").
-		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
-
-	orderID, err := pg.CreateOrder(models.PlaceOrderReq{
+	// the new order is written to the outbox in the same transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WithArgs(int64(5), int64(1), true, "BTC/USDT", "10000", "1", "limit", "filling", "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(5), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, ""))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "5", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = pg.CreateOrder(5, models.PlaceOrderReq{
 		UserID: 1,
 		IsBid:  true,
-		Symbol: "BTC/USD",
+		Symbol: "BTC/USDT",
 		Price:  "10000",
 		Qty:    "1",
 		Type:   "limit",
 	})
 	require.NoError(t, err)
-	require.Equal(t, int64(1), orderID)
+
+	// a retry with a client order ID the user already used hits the unique index
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WithArgs(int64(6), int64(1), true, "BTC/USDT", "10000", "1", "limit", "filling", "order-1").
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation, ConstraintName: clientOrderIDIndex})
+	mock.ExpectRollback()
+
+	err = pg.CreateOrder(6, models.PlaceOrderReq{
+		UserID:        1,
+		IsBid:         true,
+		Symbol:        "BTC/USDT",
+		Price:         "10000",
+		Qty:           "1",
+		Type:          "limit",
+		ClientOrderID: "order-1",
+	})
+	require.EqualError(t, err, "Duplicate client order ID")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNextOrderID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('orders', 'id'\)\)`).
+		WillReturnRows(pgxmock.NewRows([]string{"nextval"}).AddRow(int64(42)))
+
+	orderID, err := pg.NextOrderID()
+	require.NoError(t, err)
+	require.Equal(t, int64(42), orderID)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -53,10 +100,10 @@ func TestGetOrderByOrderID(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectQuery(`SELECT id,\s+userID,\s+isBid,\s+symbol,\s+price,\s+qty,\s+sizeFilled,\s+status,\s+type,\s+createdAt,\s+closedAt\s+FROM\s+orders\s+WHERE\s+id\s+=\s+\$1`).
+	mock.ExpectQuery(`SELECT id,\s+userID,\s+isBid,\s+symbol,\s+price,\s+qty,\s+sizeFilled,\s+status,\s+type,\s+createdAt,\s+closedAt,\s+clientOrderID\s+FROM\s+orders\s+WHERE\s+id\s+=\s+\$1`).
 		WithArgs(int64(1)).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt"}).
-			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil))
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, ""))
 
 	order, err := pg.GetOrderByOrderID(int64(1))
 	require.NoError(t, err)
@@ -65,6 +112,43 @@ func TestGetOrderByOrderID(t *testing.T) {
 	require.Equal(t, "10000", order.Price)
 	require.Equal(t, "1", order.Qty)
 
+	mock.ExpectQuery(`SELECT .* FROM orders WHERE id = \$1`).
+		WithArgs(int64(2)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}))
+
+	_, err = pg.GetOrderByOrderID(int64(2))
+	require.EqualError(t, err, "Order not found")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrderByClientOrderID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID FROM orders WHERE userID = \$1 AND clientOrderID = \$2`).
+		WithArgs(int64(1), "order-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, "order-1"))
+
+	order, err := pg.GetOrderByClientOrderID(1, "order-1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), order.ID)
+	require.Equal(t, "order-1", order.ClientOrderID)
+
+	mock.ExpectQuery(`SELECT .* FROM orders WHERE userID = \$1 AND clientOrderID = \$2`).
+		WithArgs(int64(1), "order-2").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}))
+
+	_, err = pg.GetOrderByClientOrderID(1, "order-2")
+	require.EqualError(t, err, "Order not found")
+
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -80,12 +164,12 @@ func TestGetOrdersByUser(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectQuery(`SELECT id,\s+userID,\s+isBid,\s+symbol,\s+price,\s+qty,\s+sizeFilled,\s+status,\s+type,\s+createdAt,\s+closedAt\s+FROM\s+orders\s+WHERE\s+userID\s+=\s+\$1`).
-		WithArgs(int64(1)).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt"}).
-			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil))
+	mock.ExpectQuery(`SELECT id,\s+userID,\s+isBid,\s+symbol,\s+price,\s+qty,\s+sizeFilled,\s+status,\s+type,\s+createdAt,\s+closedAt,\s+clientOrderID\s+FROM\s+orders\s+WHERE\s+userID\s+=\s+\$1 ORDER BY createdAt DESC, id DESC LIMIT \$2`).
+		WithArgs(int64(1), 100).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, ""))
 
-	orders, err := pg.GetOrdersByUser(int64(1))
+	orders, err := pg.GetOrdersByUser(models.OrdersFilter{UserID: 1, Limit: 100})
 	require.NoError(t, err)
 	require.Len(t, orders, 1)
 	require.Equal(t, "BTC/USDT", orders[0].Symbol)
@@ -95,6 +179,48 @@ func TestGetOrdersByUser(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetOrdersByUserFiltered(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var (
+		isBid   = false
+		from    = time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+		to      = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		afterAt = time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+	)
+
+	mock.ExpectQuery(`FROM orders WHERE userID = \$1 AND symbol = \$2 AND isBid = \$3 AND type = \$4 AND status = ANY\(\$5\) AND createdAt >= \$6 AND createdAt < \$7 AND \(createdAt, id\) < \(\$8, \$9\) ORDER BY createdAt DESC, id DESC LIMIT \$10`).
+		WithArgs(int64(1), "BTC/USDT", false, "limit", []string{"filled", "canceled"}, from, to, afterAt, int64(42), 51).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(41), int64(1), false, "BTC/USDT", "10000", "1", "1", "filled", "limit", afterAt, nil, ""))
+
+	orders, err := pg.GetOrdersByUser(models.OrdersFilter{
+		UserID:      1,
+		Symbol:      "BTC/USDT",
+		IsBid:       &isBid,
+		Type:        "limit",
+		Statuses:    []string{"filled", "canceled"},
+		CreatedFrom: from,
+		CreatedTo:   to,
+		After:       &models.OrderCursor{CreatedAt: afterAt, ID: 42},
+		Limit:       51,
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, int64(41), orders[0].ID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetNotFilledOrdersByUser(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -108,10 +234,10 @@ func TestGetNotFilledOrdersByUser(t *testing.T) {
 	}
 
 	// Экранируем скобки в регулярном выражении для IN
-	mock.ExpectQuery(`SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt FROM orders WHERE userID = \$1 AND status IN \('filling', 'canceled'\)`).
+	mock.ExpectQuery(`SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID FROM orders WHERE userID = \$1 AND status IN \('filling', 'canceled'\)`).
 		WithArgs(int64(1)).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt"}).
-			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil))
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, ""))
 
 	orders, err := pg.GetNotFilledOrdersByUser(1)
 	require.NoError(t, err)
@@ -123,6 +249,33 @@ func TestGetNotFilledOrdersByUser(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetOpenOrders(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	isBid := true
+
+	mock.ExpectQuery(`SELECT id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID FROM orders WHERE status = 'filling' AND type = 'limit' AND userID = \$1 AND symbol = \$2 AND isBid = \$3 ORDER BY createdAt, id`).
+		WithArgs(int64(1), "BTC/USDT", true).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "filling", "limit", time.Now(), nil, ""))
+
+	orders, err := pg.GetOpenOrders(models.OpenOrdersFilter{UserID: 1, Symbol: "BTC/USDT", IsBid: &isBid})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, int64(1), orders[0].ID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSetOrderStatusToCancel(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -135,9 +288,15 @@ func TestSetOrderStatusToCancel(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectExec(`UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP WHERE id = \$1`).
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP WHERE id = \$1 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID`).
 		WithArgs(int64(1)).
-		WillReturnResult(pgxmock.NewResult("UPDATE", int64(1)))
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "canceled", "limit", time.Now(), time.Now(), ""))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "1", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
 
 	err = pg.SetOrderStatusToCancel(int64(1))
 	require.NoError(t, err)
@@ -145,6 +304,40 @@ func TestSetOrderStatusToCancel(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSetOrdersStatusToCancel(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders SET status = 'canceled', closedAt = CURRENT_TIMESTAMP, cancelReason = NULLIF\(\$2, ''\) WHERE id = ANY\(\$1\) AND status = 'filling' RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID`).
+		WithArgs([]int64{1, 2}, "order book delisted").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "canceled", "limit", time.Now(), time.Now(), "").
+			AddRow(int64(2), int64(1), false, "BTC/USDT", "10100", "1", "0.5", "canceled", "limit", time.Now(), time.Now(), ""))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "1", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "2", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	orders, err := pg.SetOrdersStatusToCancel([]int64{1, 2}, "order book delisted")
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	require.Equal(t, "canceled", orders[1].Status)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSetOrderStatusToError(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -157,9 +350,15 @@ func TestSetOrderStatusToError(t *testing.T) {
 		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	mock.ExpectExec(`UPDATE orders SET status = 'error', closedAt = CURRENT_TIMESTAMP WHERE id = \$1`).
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE orders SET status = 'error', closedAt = CURRENT_TIMESTAMP WHERE id = \$1 RETURNING id, userID, isBid, symbol, price, qty, sizeFilled, status, type, createdAt, closedAt, clientOrderID`).
 		WithArgs(int64(1)).
-		WillReturnResult(pgxmock.NewResult("UPDATE", int64(1)))
+		WillReturnRows(pgxmock.NewRows([]string{"id", "userID", "isBid", "symbol", "price", "qty", "sizeFilled", "status", "type", "createdAt", "closedAt", "clientOrderID"}).
+			AddRow(int64(1), int64(1), true, "BTC/USDT", "10000", "1", "0", "error", "limit", time.Now(), time.Now(), ""))
+	mock.ExpectExec(`INSERT INTO outbox \(topic, key, payload\)`).
+		WithArgs("orders", "1", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
 
 	err = pg.SetOrderStatusToError(int64(1))
 	require.NoError(t, err)
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// addOrderEvent writes the order to the outbox as part of tx, keyed by order so
+// that the updates of one order are published in order
+func (p *Postgres) addOrderEvent(tx pgx.Tx, order models.Order, cancelReason string) error {
+	var event = models.OrderEvent{
+		ID:            order.ID,
+		UserID:        order.UserID,
+		IsBid:         order.IsBid,
+		Symbol:        order.Symbol,
+		Price:         order.Price,
+		Qty:           order.Qty,
+		SizeFilled:    order.SizeFilled,
+		Status:        order.Status,
+		Type:          order.Type,
+		CancelReason:  cancelReason,
+		ClientOrderID: order.ClientOrderID,
+		CreatedAt:     order.CreatedAt,
+	}
+	if order.ClosedAt.Valid {
+		event.ClosedAt = &order.ClosedAt.Time
+	}
+	return p.addEvent(tx, models.EventTopicOrders, strconv.FormatInt(order.ID, 10), event)
+}
+
+// addTradeEvent writes the trade to the outbox as part of tx, keyed by symbol
+// so that the trades of one symbol are published in order
+func (p *Postgres) addTradeEvent(tx pgx.Tx, trade models.Trade) error {
+	return p.addEvent(tx, models.EventTopicTrades, trade.Symbol, models.TradeEvent{
+		ID:           trade.ID,
+		Symbol:       trade.Symbol,
+		Price:        trade.Price,
+		Qty:          trade.Qty,
+		IsBid:        trade.IsBid,
+		MakerOrderID: trade.MakerOrderID,
+		TakerOrderID: trade.TakerOrderID,
+		MakerFee:     trade.MakerFee,
+		TakerFee:     trade.TakerFee,
+		FeeCurrency:  trade.FeeCurrency,
+		ExecutedAt:   trade.ExecutedAt,
+	})
+}
+
+func (p *Postgres) addEvent(tx pgx.Tx, topic, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		p.logger.Error("Error encoding event", "topic", topic, "error", err)
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(), `
+	INSERT INTO outbox (topic, key, payload)
+	VALUES ($1, $2, $3)
+	`, topic, key, data)
+	if err != nil {
+		p.logger.Error("Error inserting event", "topic", topic, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetUnpublishedEvents(limit int) ([]models.Event, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT id, topic, key, payload, createdAt
+	FROM outbox
+	WHERE publishedAt IS NULL
+	ORDER BY id
+	LIMIT $1
+	`, limit)
+	if err != nil {
+		p.logger.Error("Error selecting events", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var event models.Event
+		err := rows.Scan(
+			&event.ID,
+			&event.Topic,
+			&event.Key,
+			&event.Payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			p.logger.Error("Error scanning event", "error", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (p *Postgres) MarkEventsPublished(eventIDs []int64) error {
+	_, err := p.db.Exec(context.Background(), `
+	UPDATE outbox SET publishedAt = CURRENT_TIMESTAMP
+	WHERE id = ANY($1)
+	`, eventIDs)
+	if err != nil {
+		p.logger.Error("Error updating events", "error", err)
+		return err
+	}
+	return nil
+}
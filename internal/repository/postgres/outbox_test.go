@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUnpublishedEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	createdAt := time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, topic, key, payload, createdAt FROM outbox WHERE publishedAt IS NULL ORDER BY id LIMIT \$1`).
+		WithArgs(100).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "topic", "key", "payload", "createdAt"}).
+			AddRow(int64(1), "orders", "1", []byte(`{"id":1}`), createdAt).
+			AddRow(int64(2), "trades", "BTC/USDT", []byte(`{"id":1}`), createdAt))
+
+	events, err := pg.GetUnpublishedEvents(100)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "trades", events[1].Topic)
+	require.Equal(t, "BTC/USDT", events[1].Key)
+	require.JSONEq(t, `{"id":1}`, string(events[0].Payload))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkEventsPublished(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectExec(`UPDATE outbox SET publishedAt = CURRENT_TIMESTAMP WHERE id = ANY\(\$1\)`).
+		WithArgs([]int64{1, 2}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	require.NoError(t, pg.MarkEventsPublished([]int64{1, 2}))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
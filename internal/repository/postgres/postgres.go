@@ -13,6 +13,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Postgres stores prices and quantities in NUMERIC columns, they are passed in
+// and scanned out as decimal strings so that no precision is lost on the way
 type Postgres struct {
 	db     DB
 	logger *slog.Logger
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// SetUserLimits replaces the limits of limits.UserID in limits.Symbol
+func (p *Postgres) SetUserLimits(limits models.UserLimits) error {
+	_, err := p.db.Exec(context.Background(), `
+	INSERT INTO userLimits (userID, symbol, maxOpenOrders, maxRestingNotional, maxOrderQty)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (userID, symbol) DO UPDATE
+	SET maxOpenOrders = EXCLUDED.maxOpenOrders,
+		maxRestingNotional = EXCLUDED.maxRestingNotional,
+		maxOrderQty = EXCLUDED.maxOrderQty
+	`, limits.UserID, limits.Symbol, limits.MaxOpenOrders, limits.MaxRestingNotional, limits.MaxOrderQty)
+	if err != nil {
+		p.logger.Error("Error upserting user limits", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetUserLimits() ([]models.UserLimits, error) {
+	rows, err := p.db.Query(context.Background(), `
+	SELECT userID, symbol, maxOpenOrders, maxRestingNotional, maxOrderQty
+	FROM userLimits
+	ORDER BY userID, symbol
+	`)
+	if err != nil {
+		p.logger.Error("Error selecting user limits", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userLimits []models.UserLimits
+	for rows.Next() {
+		var limits models.UserLimits
+		err := rows.Scan(&limits.UserID, &limits.Symbol, &limits.MaxOpenOrders, &limits.MaxRestingNotional, &limits.MaxOrderQty)
+		if err != nil {
+			p.logger.Error("Error scanning user limits", "error", err)
+			return nil, err
+		}
+		userLimits = append(userLimits, limits)
+	}
+	return userLimits, nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUserLimits(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectExec(`INSERT INTO userLimits \(userID, symbol, maxOpenOrders, maxRestingNotional, maxOrderQty\) VALUES \(\$1, \$2, \$3, \$4, \$5\) ON CONFLICT \(userID, symbol\) DO UPDATE`).
+		WithArgs(int64(1), "BTC/USDT", 50, "100000", "0").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = pg.SetUserLimits(models.UserLimits{
+		UserID:             1,
+		Symbol:             "BTC/USDT",
+		MaxOpenOrders:      50,
+		MaxRestingNotional: "100000",
+		MaxOrderQty:        "0",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserLimits(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	pg := &Postgres{
+		db:     mock,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	mock.ExpectQuery(`SELECT userID, symbol, maxOpenOrders, maxRestingNotional, maxOrderQty FROM userLimits ORDER BY userID, symbol`).
+		WillReturnRows(pgxmock.NewRows([]string{"userID", "symbol", "maxOpenOrders", "maxRestingNotional", "maxOrderQty"}).
+			AddRow(int64(0), "", 200, "0", "100").
+			AddRow(int64(1), "BTC/USDT", 50, "100000", "0"))
+
+	userLimits, err := pg.GetUserLimits()
+	require.NoError(t, err)
+	require.Len(t, userLimits, 2)
+	require.Equal(t, 200, userLimits[0].MaxOpenOrders)
+	require.Equal(t, "BTC/USDT", userLimits[1].Symbol)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,20 +1,70 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 )
 
 type Storer interface {
-	CreateOrder(order models.PlaceOrderReq) (int64, error)
-	GetOrdersByUser(userID int64) ([]models.Order, error)
+	// CreateOrderBook registers a new symbol or relists a deleted one
+	CreateOrderBook(orderBook models.OrderBook) (models.OrderBook, error)
+	// DeleteOrderBook marks the book deleted and cancels its orders still filling in one transaction
+	DeleteOrderBook(symbol string, orderIDs []int64, reason string) ([]models.Order, error)
+	ScheduleOrderBookDeletion(req models.DeleteOrderBookReq) error
+	GetOrderBooks(includeDeleted bool) ([]models.OrderBook, error)
+
+	// NextOrderID takes the ID of an order before it is created
+	NextOrderID() (int64, error)
+	// CreateOrder fails with "Duplicate client order ID" when the user already used order.ClientOrderID
+	CreateOrder(orderID int64, order models.PlaceOrderReq) error
+	// GetOrdersByUser returns up to filter.Limit orders of filter.UserID, newest first
+	GetOrdersByUser(filter models.OrdersFilter) ([]models.Order, error)
 	GetOrderByOrderID(orderID int64) (models.Order, error)
+	GetOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error)
 	GetNotFilledOrdersByUser(userID int64) ([]models.Order, error)
+	GetOpenOrders(filter models.OpenOrdersFilter) ([]models.Order, error)
 
 	SetOrderStatusToError(orderID int64) error
 	SetOrderStatusToCancel(orderID int64) error
+	// SetOrdersStatusToCancel cancels the orders still filling, an empty reason stores none
+	SetOrdersStatusToCancel(orderIDs []int64, reason string) ([]models.Order, error)
 
-	AddMatches(matches AddMatchesReq) ([]models.Order, error)
+	// AddMatches stores the fills of an order and posts their ledger entries in one transaction
+	AddMatches(matches AddMatchesReq) ([]models.Order, []models.Trade, error)
 	GetMatches(orderID int64) ([]models.Match, error)
+	// GetTrades returns up to limit trades with ID above afterTradeID,
+	// an empty symbol matches every symbol
+	GetTrades(symbol string, afterTradeID int64, limit int) ([]models.Trade, error)
+	GetTradesSince(since time.Time, afterTradeID int64, limit int) ([]models.Trade, error)
+	// GetOrderFills returns the fills of the order, oldest first
+	GetOrderFills(orderID int64) ([]models.Fill, error)
+	// GetUserFills returns up to filter.Limit fills of filter.UserID, newest first
+	GetUserFills(filter models.FillsFilter) ([]models.Fill, error)
+
+	// GetBalances returns the user's ledger balance per asset, an empty asset matches every asset
+	GetBalances(userID int64, asset string) ([]models.Balance, error)
+	// GetLedgerImbalances returns the assets whose ledger entries do not sum to zero
+	GetLedgerImbalances() ([]models.LedgerImbalance, error)
+
+	// GetUnpublishedEvents returns up to limit outbox events not yet published, oldest first
+	GetUnpublishedEvents(limit int) ([]models.Event, error)
+	MarkEventsPublished(eventIDs []int64) error
+
+	// SetFeeSchedule replaces the tiers of schedule.Symbol, no tiers removes its schedule
+	SetFeeSchedule(schedule models.FeeSchedule) error
+	GetFeeSchedules() ([]models.FeeSchedule, error)
+	// GetUserVolumes returns the daily quote volume per user and symbol traded since
+	GetUserVolumes(since time.Time) ([]models.UserVolume, error)
+
+	// SetUserLimits replaces the limits of limits.UserID in limits.Symbol
+	SetUserLimits(limits models.UserLimits) error
+	GetUserLimits() ([]models.UserLimits, error)
+
+	SaveCandles(candles []models.Candle) error
+	GetCandles(symbol, interval string, from, to time.Time, limit int) ([]models.Candle, error)
+	// GetLastCandleOpenTimes returns the open time of the latest stored candle per symbol and interval
+	GetLastCandleOpenTimes() (map[string]map[string]time.Time, error)
 }
 
 type AddMatchesReq struct {
@@ -28,4 +78,7 @@ type Match struct {
 	Price                  string
 	CounterOrderID         int64
 	CounterOrderSizeFilled string
+	MakerFee               string
+	TakerFee               string
+	FeeCurrency            string
 }
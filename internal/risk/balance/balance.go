@@ -0,0 +1,87 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BazaarTrade/GeneratedProto/pb"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// callTimeout bounds every call so that a slow balance service can not stall the engine
+const callTimeout = 3 * time.Second
+
+// Client checks balances with the external balance service, see internal/proto/balance.proto
+type Client struct {
+	conn   *grpc.ClientConn
+	client pb.BalanceServiceClient
+}
+
+func New(addr string) (*Client, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:   conn,
+		client: pb.NewBalanceServiceClient(conn),
+	}, nil
+}
+
+func (c *Client) Reserve(ctx context.Context, r risk.Reservation) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	_, err := c.client.Reserve(ctx, &pb.ReserveReq{
+		OrderID: r.OrderID,
+		UserID:  r.UserID,
+		Asset:   r.Asset,
+		Amount:  r.Amount.String(),
+	})
+	if status.Code(err) == codes.FailedPrecondition {
+		return errors.New("Insufficient funds")
+	}
+	return err
+}
+
+func (c *Client) Release(ctx context.Context, orderID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	_, err := c.client.Release(ctx, &pb.ReleaseReq{OrderID: orderID})
+	return err
+}
+
+func (c *Client) Settle(ctx context.Context, fill risk.Fill) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	_, err := c.client.Settle(ctx, &pb.SettleReq{
+		TradeID:    fill.TradeID,
+		BaseAsset:  fill.BaseAsset,
+		QuoteAsset: fill.QuoteAsset,
+		Price:      fill.Price.String(),
+		Qty:        fill.Qty.String(),
+		Buyer:      partyToPb(fill.Buyer),
+		Seller:     partyToPb(fill.Seller),
+	})
+	return err
+}
+
+func partyToPb(party risk.Party) *pb.SettlementParty {
+	return &pb.SettlementParty{
+		OrderID: party.OrderID,
+		UserID:  party.UserID,
+		Fee:     party.Fee.String(),
+	}
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/shopspring/decimal"
+)
+
+// FeeAccount is the user the fees of every trade are credited to
+const FeeAccount = models.FeeAccountUserID
+
+type accountKey struct {
+	userID int64
+	asset  string
+}
+
+type balance struct {
+	available decimal.Decimal
+	reserved  decimal.Decimal
+}
+
+type reservation struct {
+	account accountKey
+	amount  decimal.Decimal
+}
+
+// Ledger keeps account balances in process, for local runs and tests. Every
+// account a user opens is credited with the initial balance of its asset, which
+// lets a local exchange trade without a deposit flow
+type Ledger struct {
+	mu           sync.Mutex
+	accounts     map[accountKey]*balance
+	reservations map[int64]*reservation //by order ID
+	initial      map[string]decimal.Decimal
+}
+
+func New(initial map[string]decimal.Decimal) *Ledger {
+	return &Ledger{
+		accounts:     make(map[accountKey]*balance),
+		reservations: make(map[int64]*reservation),
+		initial:      initial,
+	}
+}
+
+// account returns the balance of the user's asset, opening the account on first use, caller holds l.mu
+func (l *Ledger) account(key accountKey) *balance {
+	b, ok := l.accounts[key]
+	if !ok {
+		b = &balance{}
+		if key.userID != FeeAccount {
+			b.available = l.initial[key.asset]
+		}
+		l.accounts[key] = b
+	}
+	return b
+}
+
+func (l *Ledger) Deposit(userID int64, asset string, amount decimal.Decimal) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.account(accountKey{userID: userID, asset: asset})
+	b.available = b.available.Add(amount)
+}
+
+func (l *Ledger) Balance(userID int64, asset string) (available, reserved decimal.Decimal) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.account(accountKey{userID: userID, asset: asset})
+	return b.available, b.reserved
+}
+
+func (l *Ledger) Reserve(ctx context.Context, r risk.Reservation) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.reservations[r.OrderID]; ok {
+		return errors.New("Order already has a reservation")
+	}
+
+	var (
+		key = accountKey{userID: r.UserID, asset: r.Asset}
+		b   = l.account(key)
+	)
+	if b.available.LessThan(r.Amount) {
+		return errors.New("Insufficient funds")
+	}
+
+	b.available = b.available.Sub(r.Amount)
+	b.reserved = b.reserved.Add(r.Amount)
+	l.reservations[r.OrderID] = &reservation{account: key, amount: r.Amount}
+	return nil
+}
+
+func (l *Ledger) Release(ctx context.Context, orderID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res, ok := l.reservations[orderID]
+	if !ok {
+		return nil
+	}
+
+	b := l.account(res.account)
+	b.reserved = b.reserved.Sub(res.amount)
+	b.available = b.available.Add(res.amount)
+	delete(l.reservations, orderID)
+	return nil
+}
+
+func (l *Ledger) Settle(ctx context.Context, fill risk.Fill) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		value      = fill.Price.Mul(fill.Qty)
+		buyerPays  = value.Add(fill.Buyer.Fee)
+		buyerQuote = accountKey{userID: fill.Buyer.UserID, asset: fill.QuoteAsset}
+		sellerBase = accountKey{userID: fill.Seller.UserID, asset: fill.BaseAsset}
+	)
+
+	// both sides are checked before either pays so that a trade is settled whole or not at all
+	if l.spendable(fill.Buyer.OrderID, buyerQuote).LessThan(buyerPays) || l.spendable(fill.Seller.OrderID, sellerBase).LessThan(fill.Qty) {
+		return errors.New("Insufficient funds")
+	}
+
+	if err := l.debit(fill.Buyer.OrderID, buyerQuote, buyerPays); err != nil {
+		return err
+	}
+	l.credit(accountKey{userID: fill.Buyer.UserID, asset: fill.BaseAsset}, fill.Qty)
+
+	if err := l.debit(fill.Seller.OrderID, sellerBase, fill.Qty); err != nil {
+		return err
+	}
+	l.credit(accountKey{userID: fill.Seller.UserID, asset: fill.QuoteAsset}, value.Sub(fill.Seller.Fee))
+
+	l.credit(accountKey{userID: FeeAccount, asset: fill.QuoteAsset}, fill.Buyer.Fee.Add(fill.Seller.Fee))
+	return nil
+}
+
+// spendable is what the order may spend out of the account: what is left of its
+// reservation and the available balance, caller holds l.mu
+func (l *Ledger) spendable(orderID int64, key accountKey) decimal.Decimal {
+	var amount = l.account(key).available
+	if res, ok := l.reservations[orderID]; ok && res.account == key {
+		amount = amount.Add(res.amount)
+	}
+	return amount
+}
+
+// debit takes amount out of the order's reservation and the rest, if the
+// reservation falls short, out of the available balance. It fails without
+// taking anything when both together fall short, caller holds l.mu
+func (l *Ledger) debit(orderID int64, key accountKey, amount decimal.Decimal) error {
+	if l.spendable(orderID, key).LessThan(amount) {
+		return errors.New("Insufficient funds")
+	}
+
+	var (
+		b    = l.account(key)
+		rest = amount
+	)
+
+	if res, ok := l.reservations[orderID]; ok && res.account == key {
+		taken := decimal.Min(res.amount, amount)
+		res.amount = res.amount.Sub(taken)
+		b.reserved = b.reserved.Sub(taken)
+		rest = rest.Sub(taken)
+	}
+	b.available = b.available.Sub(rest)
+	return nil
+}
+
+// credit adds amount to the available balance, caller holds l.mu
+func (l *Ledger) credit(key accountKey, amount decimal.Decimal) {
+	b := l.account(key)
+	b.available = b.available.Add(amount)
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func requireBalance(t *testing.T, l *Ledger, userID int64, asset, available, reserved string) {
+	t.Helper()
+	a, r := l.Balance(userID, asset)
+	require.True(t, decimal.RequireFromString(available).Equal(a), "%s available of user %d is %s", asset, userID, a)
+	require.True(t, decimal.RequireFromString(reserved).Equal(r), "%s reserved of user %d is %s", asset, userID, r)
+}
+
+func TestLedger(t *testing.T) {
+	var (
+		ctx = context.Background()
+		l   = New(map[string]decimal.Decimal{"USDT": decimal.RequireFromString("1000")})
+	)
+	l.Deposit(2, "BTC", decimal.RequireFromString("1"))
+
+	// the buyer reserves the order value plus fees
+	require.NoError(t, l.Reserve(ctx, risk.Reservation{OrderID: 1, UserID: 1, Asset: "USDT", Amount: decimal.RequireFromString("505")}))
+	requireBalance(t, l, 1, "USDT", "495", "505")
+
+	err := l.Reserve(ctx, risk.Reservation{OrderID: 2, UserID: 1, Asset: "USDT", Amount: decimal.RequireFromString("500")})
+	require.EqualError(t, err, "Insufficient funds")
+
+	require.NoError(t, l.Reserve(ctx, risk.Reservation{OrderID: 3, UserID: 2, Asset: "BTC", Amount: decimal.RequireFromString("1")}))
+
+	require.NoError(t, l.Settle(ctx, risk.Fill{
+		TradeID:    1,
+		BaseAsset:  "BTC",
+		QuoteAsset: "USDT",
+		Price:      decimal.RequireFromString("490"),
+		Qty:        decimal.RequireFromString("0.5"),
+		Buyer:      risk.Party{OrderID: 1, UserID: 1, Fee: decimal.RequireFromString("1")},
+		Seller:     risk.Party{OrderID: 3, UserID: 2, Fee: decimal.RequireFromString("-0.5")},
+	}))
+	requireBalance(t, l, 1, "USDT", "495", "259")
+	requireBalance(t, l, 1, "BTC", "0.5", "0")
+	requireBalance(t, l, 2, "BTC", "0", "0.5")
+	requireBalance(t, l, 2, "USDT", "1245.5", "0")
+	requireBalance(t, l, FeeAccount, "USDT", "0.5", "0")
+
+	// closing the orders returns what they did not spend
+	require.NoError(t, l.Release(ctx, 1))
+	require.NoError(t, l.Release(ctx, 3))
+	require.NoError(t, l.Release(ctx, 3))
+	requireBalance(t, l, 1, "USDT", "754", "0")
+	requireBalance(t, l, 2, "BTC", "0.5", "0")
+}
+
+func TestSettleWithoutFunds(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		l    = New(nil)
+		fill = risk.Fill{
+			TradeID:    1,
+			BaseAsset:  "BTC",
+			QuoteAsset: "USDT",
+			Price:      decimal.RequireFromString("500"),
+			Qty:        decimal.RequireFromString("1"),
+			Buyer:      risk.Party{OrderID: 1, UserID: 1, Fee: decimal.RequireFromString("1")},
+			Seller:     risk.Party{OrderID: 2, UserID: 2, Fee: decimal.Zero},
+		}
+	)
+	l.Deposit(1, "USDT", decimal.RequireFromString("400"))
+	l.Deposit(2, "BTC", decimal.RequireFromString("1"))
+	require.NoError(t, l.Reserve(ctx, risk.Reservation{OrderID: 1, UserID: 1, Asset: "USDT", Amount: decimal.RequireFromString("300")}))
+	require.NoError(t, l.Reserve(ctx, risk.Reservation{OrderID: 2, UserID: 2, Asset: "BTC", Amount: decimal.RequireFromString("1")}))
+
+	// the buyer's reservation and balance cover 400 of the 501 it owes, nobody pays
+	require.EqualError(t, l.Settle(ctx, fill), "Insufficient funds")
+	requireBalance(t, l, 1, "USDT", "100", "300")
+	requireBalance(t, l, 1, "BTC", "0", "0")
+	requireBalance(t, l, 2, "BTC", "0", "1")
+	requireBalance(t, l, 2, "USDT", "0", "0")
+
+	// a reservation short of the trade is topped up out of the available balance
+	l.Deposit(1, "USDT", decimal.RequireFromString("101"))
+	require.NoError(t, l.Settle(ctx, fill))
+	requireBalance(t, l, 1, "USDT", "0", "0")
+	requireBalance(t, l, 1, "BTC", "1", "0")
+	requireBalance(t, l, 2, "USDT", "500", "0")
+}
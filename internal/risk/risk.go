@@ -0,0 +1,56 @@
+package risk
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// Checker holds the funds of an open order so that the user can always pay for
+// what the order trades. A reservation is keyed by order ID and shrinks as the
+// order fills, whatever is left is released once the order is closed
+type Checker interface {
+	// Reserve fails with "Insufficient funds" when the user can not cover the amount
+	Reserve(ctx context.Context, reservation Reservation) error
+	// Release frees what is left of the order's reservation, an unknown order is a no-op
+	Release(ctx context.Context, orderID int64) error
+	// Settle exchanges the assets of a trade, each side pays out of its order's reservation
+	Settle(ctx context.Context, fill Fill) error
+}
+
+// Reservation is what an order may spend: the quote asset at the limit price plus
+// fees for bids, the base asset for asks
+type Reservation struct {
+	OrderID int64
+	UserID  int64
+	Asset   string
+	Amount  decimal.Decimal
+}
+
+// Fill is a trade seen from its two sides. The buyer pays Price*Qty plus its fee
+// and the seller receives Price*Qty less its fee, both fees are in the quote asset
+// and a negative fee is a rebate
+type Fill struct {
+	TradeID    int64
+	BaseAsset  string
+	QuoteAsset string
+	Price      decimal.Decimal
+	Qty        decimal.Decimal
+	Buyer      Party
+	Seller     Party
+}
+
+type Party struct {
+	OrderID int64
+	UserID  int64
+	Fee     decimal.Decimal
+}
+
+// Disabled accepts every order, for deployments where balances are checked upstream
+type Disabled struct{}
+
+func (Disabled) Reserve(ctx context.Context, reservation Reservation) error { return nil }
+
+func (Disabled) Release(ctx context.Context, orderID int64) error { return nil }
+
+func (Disabled) Settle(ctx context.Context, fill Fill) error { return nil }
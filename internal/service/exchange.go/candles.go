@@ -0,0 +1,291 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// candlesBuffer is how far a candle subscriber may fall behind before it is dropped
+	candlesBuffer = 256
+	maxCandles    = 1000
+
+	candlesBackfillPage = 1000
+)
+
+var candleIntervals = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+func candleInterval(name string) (time.Duration, error) {
+	for _, interval := range candleIntervals {
+		if interval.name == name {
+			return interval.duration, nil
+		}
+	}
+	return 0, errors.New("unknown candle interval")
+}
+
+type candleKey struct {
+	symbol   string
+	interval string
+}
+
+type candle struct {
+	openTime    time.Time
+	open        decimal.Decimal
+	high        decimal.Decimal
+	low         decimal.Decimal
+	close       decimal.Decimal
+	volume      decimal.Decimal
+	quoteVolume decimal.Decimal
+	tradeCount  int
+}
+
+func (c *candle) toModel(key candleKey, duration time.Duration, closed bool) models.Candle {
+	return models.Candle{
+		Symbol:      key.symbol,
+		Interval:    key.interval,
+		OpenTime:    c.openTime,
+		CloseTime:   c.openTime.Add(duration),
+		Open:        c.open.String(),
+		High:        c.high.String(),
+		Low:         c.low.String(),
+		Close:       c.close.String(),
+		Volume:      c.volume.String(),
+		QuoteVolume: c.quoteVolume.String(),
+		TradeCount:  c.tradeCount,
+		Closed:      closed,
+	}
+}
+
+// candleAggregator keeps the open OHLCV candle of every symbol and interval
+// in memory and stores candles once their interval is over
+type candleAggregator struct {
+	mu      sync.Mutex
+	current map[candleKey]*candle
+	updates *keyedFeed[candleKey, models.Candle]
+
+	db     repository.Storer
+	logger *slog.Logger
+}
+
+func newCandleAggregator(db repository.Storer, logger *slog.Logger) *candleAggregator {
+	return &candleAggregator{
+		current: make(map[candleKey]*candle),
+		updates: newKeyedFeed[candleKey, models.Candle](),
+		db:      db,
+		logger:  logger,
+	}
+}
+
+func (a *candleAggregator) record(trade models.Trade) {
+	a.save(a.add(trade, nil))
+}
+
+// add applies the trade to the open candle of every interval except those
+// already stored up to persistedUntil, it returns the candles the trade closed
+func (a *candleAggregator) add(trade models.Trade, persistedUntil map[candleKey]time.Time) []models.Candle {
+	price, err := decimal.NewFromString(trade.Price)
+	if err != nil {
+		a.logger.Error("Error converting price to decimal", "tradeID", trade.ID, "error", err)
+		return nil
+	}
+
+	qty, err := decimal.NewFromString(trade.Qty)
+	if err != nil {
+		a.logger.Error("Error converting qty to decimal", "tradeID", trade.ID, "error", err)
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var closed []models.Candle
+	for _, interval := range candleIntervals {
+		key := candleKey{symbol: trade.Symbol, interval: interval.name}
+		if trade.ExecutedAt.Before(persistedUntil[key]) {
+			continue
+		}
+
+		var (
+			openTime = trade.ExecutedAt.UTC().Truncate(interval.duration)
+			c        = a.current[key]
+		)
+
+		if c != nil && openTime.After(c.openTime) {
+			closedCandle := c.toModel(key, interval.duration, true)
+			a.updates.publish(key, closedCandle)
+			closed = append(closed, closedCandle)
+			c = nil
+		}
+
+		if c == nil {
+			c = &candle{
+				openTime: openTime,
+				open:     price,
+				high:     price,
+				low:      price,
+			}
+			a.current[key] = c
+		}
+
+		c.high = decimal.Max(c.high, price)
+		c.low = decimal.Min(c.low, price)
+		c.close = price
+		c.volume = c.volume.Add(qty)
+		c.quoteVolume = c.quoteVolume.Add(price.Mul(qty))
+		c.tradeCount++
+
+		a.updates.publish(key, c.toModel(key, interval.duration, false))
+	}
+	return closed
+}
+
+// closeExpired closes the candles whose interval is over even if no trade followed
+func (a *candleAggregator) closeExpired(now time.Time) []models.Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var closed []models.Candle
+	for _, interval := range candleIntervals {
+		for key, c := range a.current {
+			if key.interval != interval.name || now.Before(c.openTime.Add(interval.duration)) {
+				continue
+			}
+
+			closedCandle := c.toModel(key, interval.duration, true)
+			a.updates.publish(key, closedCandle)
+			closed = append(closed, closedCandle)
+			delete(a.current, key)
+		}
+	}
+	return closed
+}
+
+func (a *candleAggregator) save(candles []models.Candle) {
+	if len(candles) == 0 {
+		return
+	}
+
+	if err := a.db.SaveCandles(candles); err != nil {
+		a.logger.Error("Failed to save candles", "count", len(candles), "error", err)
+	}
+}
+
+// backfill rebuilds the open candles, and stores the missing closed ones,
+// from the trades executed after the latest stored candle of every symbol
+// and interval, a symbol without stored candles is rebuilt from its first trade
+func (a *candleAggregator) backfill() error {
+	lastOpenTimes, err := a.db.GetLastCandleOpenTimes()
+	if err != nil {
+		return err
+	}
+
+	orderBooks, err := a.db.GetOrderBooks(true)
+	if err != nil {
+		return err
+	}
+
+	var (
+		persistedUntil = make(map[candleKey]time.Time)
+		since          time.Time
+	)
+	for i, orderBook := range orderBooks {
+		for j, interval := range candleIntervals {
+			key := candleKey{symbol: orderBook.Symbol, interval: interval.name}
+			if openTime, ok := lastOpenTimes[orderBook.Symbol][interval.name]; ok {
+				persistedUntil[key] = openTime.Add(interval.duration)
+			}
+			if (i == 0 && j == 0) || persistedUntil[key].Before(since) {
+				since = persistedUntil[key]
+			}
+		}
+	}
+
+	var (
+		afterTradeID int64
+		count        int
+	)
+	for {
+		trades, err := a.db.GetTradesSince(since, afterTradeID, candlesBackfillPage)
+		if err != nil {
+			return err
+		}
+
+		for _, trade := range trades {
+			a.save(a.add(trade, persistedUntil))
+			afterTradeID = trade.ID
+		}
+		count += len(trades)
+
+		if len(trades) < candlesBackfillPage {
+			break
+		}
+	}
+
+	a.save(a.closeExpired(time.Now().UTC()))
+	a.logger.Info("Candles backfilled", "since", since, "trades", count)
+	return nil
+}
+
+func (a *candleAggregator) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			a.save(a.closeExpired(now.UTC()))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *candleAggregator) candles(symbol, interval string, from, to time.Time) ([]models.Candle, error) {
+	duration, err := candleInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := a.db.GetCandles(symbol, interval, from, to, maxCandles)
+	if err != nil {
+		return nil, err
+	}
+	for i := range candles {
+		candles[i].CloseTime = candles[i].OpenTime.Add(duration)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := candleKey{symbol: symbol, interval: interval}
+	if c, ok := a.current[key]; ok && len(candles) < maxCandles && !c.openTime.Before(from) && c.openTime.Before(to) {
+		candles = append(candles, c.toModel(key, duration, false))
+	}
+	return candles, nil
+}
+
+func (a *candleAggregator) subscribe(symbol, interval string) (chan models.Candle, func(), error) {
+	if _, err := candleInterval(interval); err != nil {
+		return nil, nil, err
+	}
+
+	key := candleKey{symbol: symbol, interval: interval}
+	candles := a.updates.subscribe(key, candlesBuffer)
+	return candles, func() { a.updates.unsubscribe(key, candles) }, nil
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"errors"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// maxClientOrderIDLength matches the orders.clientOrderID column
+const maxClientOrderIDLength = 64
+
+// placedOrder returns the order placed first under the client order ID of input.
+// A retry has to describe the same order, otherwise the ID is being reused
+func placedOrder(input models.PlaceOrderReq, order models.Order) ([]models.Order, error) {
+	if order.Symbol != input.Symbol || order.IsBid != input.IsBid || order.Type != input.Type ||
+		!sameDecimal(order.Price, input.Price) || !sameDecimal(order.Qty, input.Qty) {
+		return nil, errors.New("Client order ID already used")
+	}
+	return []models.Order{order}, nil
+}
+
+func sameDecimal(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	aDecimal, err := decimal.NewFromString(a)
+	if err != nil {
+		return false
+	}
+
+	bDecimal, err := decimal.NewFromString(b)
+	if err != nil {
+		return false
+	}
+	return aDecimal.Equal(bDecimal)
+}
+
+// placedByClientOrderID looks up an order the user already placed under input.ClientOrderID,
+// found is false when there is none
+func (e *Exchange) placedByClientOrderID(input models.PlaceOrderReq) (orders []models.Order, found bool, err error) {
+	order, err := e.db.GetOrderByClientOrderID(input.UserID, input.ClientOrderID)
+	if err != nil {
+		if err.Error() == "Order not found" {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	e.logger.Info("Order already placed", "userID", input.UserID, "clientOrderID", input.ClientOrderID, "orderID", order.ID)
+
+	orders, err = placedOrder(input, order)
+	return orders, true, err
+}
+
+func (e *Exchange) GetOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error) {
+	return e.db.GetOrderByClientOrderID(userID, clientOrderID)
+}
+
+func (e *Exchange) CancelOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error) {
+	if !e.ready.Load() {
+		return models.Order{}, errors.New("Exchange is recovering")
+	}
+
+	order, err := e.db.GetOrderByClientOrderID(userID, clientOrderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return e.CancelOrder(order.ID)
+}
@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacedOrder(t *testing.T) {
+	var (
+		order = models.Order{
+			ID:            7,
+			UserID:        1,
+			IsBid:         true,
+			Symbol:        "BTC/USDT",
+			Price:         "10000.00",
+			Qty:           "1",
+			SizeFilled:    "0.4",
+			Status:        "filling",
+			Type:          "limit",
+			ClientOrderID: "order-1",
+		}
+		retry = models.PlaceOrderReq{
+			UserID:        1,
+			IsBid:         true,
+			Symbol:        "BTC/USDT",
+			Price:         "10000",
+			Qty:           "1.0",
+			Type:          "limit",
+			ClientOrderID: "order-1",
+		}
+	)
+
+	orders, err := placedOrder(retry, order)
+	require.NoError(t, err)
+	require.Equal(t, []models.Order{order}, orders)
+
+	retry.Qty = "2"
+	_, err = placedOrder(retry, order)
+	require.EqualError(t, err, "Client order ID already used")
+}
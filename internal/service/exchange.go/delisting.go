@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+// defaultDeleteReason is stored on the orders canceled by a deletion that gave no reason
+const defaultDeleteReason = "order book deleted"
+
+// deleteOrderBook deletes the book in the registry together with the orders
+// that rest in it, then removes it from the engine
+func (e *Exchange) deleteOrderBook(req models.DeleteOrderBookReq) ([]models.Order, error) {
+	ob, ok := e.orderBook(req.Symbol)
+	if !ok {
+		e.logger.Error("Order book not found")
+		return nil, errors.New("Order book not found")
+	}
+
+	reason := deleteReason(req.Reason)
+	canceledOrders, err := e.removeOrderBook(ob, req.CancelOrders, func(orderIDs []int64) ([]models.Order, error) {
+		return e.db.DeleteOrderBook(req.Symbol, orderIDs, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.unscheduleDeletion(req.Symbol)
+	e.reportCancel(reason, canceledOrders...)
+
+	e.logger.Info("Order book deleted successfully", "symbol", req.Symbol, "canceledOrders", len(canceledOrders))
+	return canceledOrders, nil
+}
+
+// removeOrderBook stores the deletion with store while the book takes no
+// commands, then journals it and drops the book. store gets the IDs of the
+// resting orders and returns those it canceled, the book is left as it was
+// when store fails. Commands already waiting for the book fail once they get
+// it, see Exchange.execute
+func (e *Exchange) removeOrderBook(ob *OrderBook, cancelOrders bool, store func(orderIDs []int64) ([]models.Order, error)) ([]models.Order, error) {
+	ob.commandMutex.Lock()
+	defer ob.commandMutex.Unlock()
+
+	if ob.deleted {
+		return nil, errors.New("Order book not found")
+	}
+
+	var (
+		resting  = ob.restingOrders()
+		orderIDs []int64
+	)
+	for _, order := range append(resting.Bids, resting.Asks...) {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	if len(orderIDs) > 0 && !cancelOrders {
+		e.logger.Error("Order book has resting orders", "symbol", ob.symbol, "count", len(orderIDs))
+		return nil, errors.New("Order book has resting orders")
+	}
+
+	canceledOrders, err := store(orderIDs)
+	if err != nil {
+		e.logger.Error("Failed to delete order book", "symbol", ob.symbol, "error", err)
+		return nil, err
+	}
+
+	var cmd = command{
+		Type:   commandDeleteBook,
+		Symbol: ob.symbol,
+	}
+	if err := e.journal.append(&cmd); err != nil {
+		// the registry holds the deletion, recovery drops the book, see Exchange.syncOrderBooks
+		e.logger.Error("Failed to journal command", "type", cmd.Type, "symbol", cmd.Symbol, "error", err)
+	}
+	ob.deleted = true
+
+	e.orderBooksMutex.Lock()
+	delete(e.orderBooks, ob.symbol)
+	delete(e.instruments, ob.symbol)
+	e.orderBooksMutex.Unlock()
+
+	ob.levelUpdates.close()
+
+	e.limits.remove(orderIDs...)
+	e.release(orderIDs...)
+	return canceledOrders, nil
+}
+
+// deleteReason is the cancel reason stored on the orders of a deleted book
+func deleteReason(reason string) string {
+	if reason == "" {
+		return defaultDeleteReason
+	}
+	return reason
+}
+
+// scheduleDeletion deletes the book at req.ScheduledAt, replacing a deletion scheduled before
+func (e *Exchange) scheduleDeletion(req models.DeleteOrderBookReq) {
+	e.deletionsMutex.Lock()
+	defer e.deletionsMutex.Unlock()
+
+	if timer, ok := e.deletions[req.Symbol]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(req.ScheduledAt), func() {
+		e.deletionsMutex.Lock()
+		if e.deletions[req.Symbol] == timer {
+			delete(e.deletions, req.Symbol)
+		}
+		e.deletionsMutex.Unlock()
+
+		if _, err := e.deleteOrderBook(req); err != nil {
+			e.logger.Error("Scheduled order book deletion failed", "symbol", req.Symbol, "error", err)
+		}
+	})
+	e.deletions[req.Symbol] = timer
+}
+
+func (e *Exchange) unscheduleDeletion(symbol string) {
+	e.deletionsMutex.Lock()
+	defer e.deletionsMutex.Unlock()
+
+	if timer, ok := e.deletions[symbol]; ok {
+		timer.Stop()
+		delete(e.deletions, symbol)
+	}
+}
@@ -0,0 +1,160 @@
+package exchange
+
+import (
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// levelUpdatesBuffer is how far a subscriber may fall behind before it is dropped
+const levelUpdatesBuffer = 4096
+
+// levelChanged must be called with the side mutex of the limit held
+func (ob *OrderBook) levelChanged(isBid bool, limit *Limit) {
+	ob.feedMutex.Lock()
+	defer ob.feedMutex.Unlock()
+
+	ob.sequence++
+	ob.levelUpdates.publish(models.PriceLevelUpdate{
+		Symbol:     ob.symbol,
+		Sequence:   ob.sequence,
+		IsBid:      isBid,
+		Price:      limit.price.String(),
+		Size:       limit.totalSize.String(),
+		OrderCount: len(limit.orders),
+	})
+}
+
+// subscribe registers for level updates and returns the full book they apply to
+func (ob *OrderBook) subscribe() (models.OrderBookSnapshot, chan models.PriceLevelUpdate) {
+	ob.bidMutex.RLock()
+	defer ob.bidMutex.RUnlock()
+	ob.askMutex.RLock()
+	defer ob.askMutex.RUnlock()
+
+	snapshot := models.OrderBookSnapshot{
+		Symbol:   ob.symbol,
+		Sequence: ob.sequence,
+		Bids:     aggregateLimits(ob.bestBidLimits, len(ob.bestBidLimits), decimal.Zero, true),
+		Asks:     aggregateLimits(ob.bestAskLimits, len(ob.bestAskLimits), decimal.Zero, false),
+	}
+	return snapshot, ob.levelUpdates.subscribe(levelUpdatesBuffer)
+}
+
+// depth returns up to depth aggregated levels per side. With a positive
+// grouping prices are rounded into buckets of that size, bids down and asks up
+func (ob *OrderBook) depth(depth int, grouping decimal.Decimal) models.OrderBookSnapshot {
+	ob.bidMutex.RLock()
+	defer ob.bidMutex.RUnlock()
+	ob.askMutex.RLock()
+	defer ob.askMutex.RUnlock()
+
+	return models.OrderBookSnapshot{
+		Symbol:   ob.symbol,
+		Sequence: ob.sequence,
+		Bids:     aggregateLimits(ob.bestBidLimits, depth, grouping, true),
+		Asks:     aggregateLimits(ob.bestAskLimits, depth, grouping, false),
+	}
+}
+
+// restingOrders returns every order in the book, bids and asks from the best price
+func (ob *OrderBook) restingOrders() models.OrderBookL3Snapshot {
+	ob.bidMutex.RLock()
+	defer ob.bidMutex.RUnlock()
+	ob.askMutex.RLock()
+	defer ob.askMutex.RUnlock()
+
+	return models.OrderBookL3Snapshot{
+		Symbol:   ob.symbol,
+		Sequence: ob.sequence,
+		Bids:     collectOrders(ob.bestBidLimits),
+		Asks:     collectOrders(ob.bestAskLimits),
+	}
+}
+
+func collectOrders(bestLimits []*Limit) []models.RestingOrder {
+	var orders []models.RestingOrder
+	for _, limit := range bestLimits {
+		for i, order := range limit.orders {
+			orders = append(orders, models.RestingOrder{
+				ID:            order.ID,
+				UserID:        order.userID,
+				IsBid:         order.isBid,
+				Price:         limit.price.String(),
+				Qty:           order.qty.String(),
+				QueuePosition: i + 1,
+				CreatedAt:     order.createdAt,
+			})
+		}
+	}
+	return orders
+}
+
+func aggregateLimits(bestLimits []*Limit, depth int, grouping decimal.Decimal, isBid bool) []models.PriceLevel {
+	var (
+		levels     []models.PriceLevel
+		levelPrice decimal.Decimal
+		levelSize  decimal.Decimal
+		orderCount int
+	)
+
+	flush := func() {
+		if orderCount > 0 {
+			levels = append(levels, models.PriceLevel{
+				Price:      levelPrice.String(),
+				Size:       levelSize.String(),
+				OrderCount: orderCount,
+			})
+		}
+	}
+
+	for _, limit := range bestLimits {
+		if limit.totalSize.IsZero() {
+			continue
+		}
+
+		price := groupPrice(limit.price, grouping, isBid)
+		if orderCount == 0 || !price.Equal(levelPrice) {
+			flush()
+			if len(levels) == depth {
+				return levels
+			}
+			levelPrice, levelSize, orderCount = price, decimal.Zero, 0
+		}
+
+		levelSize = levelSize.Add(limit.totalSize)
+		orderCount += len(limit.orders)
+	}
+	flush()
+
+	return levels
+}
+
+func groupPrice(price, grouping decimal.Decimal, isBid bool) decimal.Decimal {
+	if !grouping.IsPositive() {
+		return price
+	}
+
+	buckets := price.Div(grouping)
+	if isBid {
+		return buckets.Floor().Mul(grouping)
+	}
+	return buckets.Ceil().Mul(grouping)
+}
+
+// askCost returns what buying qty off the asks costs, or what buying every ask
+// costs when there is less than qty
+func (ob *OrderBook) askCost(qty decimal.Decimal) decimal.Decimal {
+	ob.askMutex.RLock()
+	defer ob.askMutex.RUnlock()
+
+	var cost decimal.Decimal
+	for _, limit := range ob.bestAskLimits {
+		if !qty.IsPositive() {
+			break
+		}
+		size := decimal.Min(qty, limit.totalSize)
+		cost = cost.Add(size.Mul(limit.price))
+		qty = qty.Sub(size)
+	}
+	return cost
+}
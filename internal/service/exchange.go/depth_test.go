@@ -0,0 +1,110 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// testLimits builds limits from price and size pairs, one order per limit
+func testLimits(levels ...[2]string) []*Limit {
+	var limits []*Limit
+	for _, level := range levels {
+		limits = append(limits, &Limit{
+			price:     decimal.RequireFromString(level[0]),
+			orders:    []*Order{{}},
+			totalSize: decimal.RequireFromString(level[1]),
+		})
+	}
+	return limits
+}
+
+func TestGroupPrice(t *testing.T) {
+	for _, tc := range []struct {
+		price    string
+		grouping string
+		isBid    bool
+		want     string
+	}{
+		{"100.37", "0", true, "100.37"},
+		{"100.37", "0", false, "100.37"},
+		{"100.37", "1", true, "100"},
+		{"100.37", "1", false, "101"},
+		{"100.37", "0.1", true, "100.3"},
+		{"100.37", "0.1", false, "100.4"},
+		{"100", "10", true, "100"},
+		{"100", "10", false, "100"},
+		{"105", "10", true, "100"},
+		{"105", "10", false, "110"},
+	} {
+		got := groupPrice(decimal.RequireFromString(tc.price), decimal.RequireFromString(tc.grouping), tc.isBid)
+		require.True(t, decimal.RequireFromString(tc.want).Equal(got), "price %s grouped by %s (bid %t) is %s", tc.price, tc.grouping, tc.isBid, got)
+	}
+}
+
+func TestAggregateLimits(t *testing.T) {
+	var (
+		bids = testLimits([2]string{"100.5", "1"}, [2]string{"100.2", "2"}, [2]string{"99.9", "0"}, [2]string{"99.5", "3"}, [2]string{"98", "4"})
+		asks = testLimits([2]string{"100.6", "1"}, [2]string{"100.9", "2"}, [2]string{"101.4", "3"}, [2]string{"103", "4"})
+	)
+
+	for _, tc := range []struct {
+		name     string
+		limits   []*Limit
+		depth    int
+		grouping string
+		isBid    bool
+		want     []models.PriceLevel
+	}{
+		{
+			name:   "bids ungrouped skip empty limits",
+			limits: bids, depth: 10, grouping: "0", isBid: true,
+			want: []models.PriceLevel{
+				{Price: "100.5", Size: "1", OrderCount: 1},
+				{Price: "100.2", Size: "2", OrderCount: 1},
+				{Price: "99.5", Size: "3", OrderCount: 1},
+				{Price: "98", Size: "4", OrderCount: 1},
+			},
+		},
+		{
+			name:   "bids round down",
+			limits: bids, depth: 10, grouping: "1", isBid: true,
+			want: []models.PriceLevel{
+				{Price: "100", Size: "3", OrderCount: 2},
+				{Price: "99", Size: "3", OrderCount: 1},
+				{Price: "98", Size: "4", OrderCount: 1},
+			},
+		},
+		{
+			name:   "asks round up",
+			limits: asks, depth: 10, grouping: "1", isBid: false,
+			want: []models.PriceLevel{
+				{Price: "101", Size: "3", OrderCount: 2},
+				{Price: "102", Size: "3", OrderCount: 1},
+				{Price: "103", Size: "4", OrderCount: 1},
+			},
+		},
+		{
+			name:   "depth counts grouped levels",
+			limits: bids, depth: 2, grouping: "1", isBid: true,
+			want: []models.PriceLevel{
+				{Price: "100", Size: "3", OrderCount: 2},
+				{Price: "99", Size: "3", OrderCount: 1},
+			},
+		},
+		{
+			name:   "depth cuts ungrouped levels",
+			limits: asks, depth: 1, grouping: "0", isBid: false,
+			want: []models.PriceLevel{
+				{Price: "100.6", Size: "1", OrderCount: 1},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := aggregateLimits(tc.limits, tc.depth, decimal.RequireFromString(tc.grouping), tc.isBid)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
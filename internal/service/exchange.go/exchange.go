@@ -1,50 +1,299 @@
 package exchange
 
 import (
+	"context"
 	"errors"
 	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
 	"github.com/shopspring/decimal"
 )
 
+// tradesBuffer is how far a trade subscriber may fall behind before it is dropped
+const tradesBuffer = 4096
+
 type Exchange struct {
-	db         repository.Storer
-	orderBooks map[string]*OrderBook
+	db      repository.Storer
+	risk    risk.Checker
+	cfg     config.Config
+	journal *journal
+	ready   atomic.Bool //set once Start has recovered the order books
+
+	orderBooksMutex sync.RWMutex
+	orderBooks      map[string]*OrderBook
+	instruments     map[string]instrument
+
+	deletionsMutex sync.Mutex
+	deletions      map[string]*time.Timer //scheduled order book deletions
+
+	trades     *feed[models.Trade]
+	executions *keyedFeed[int64, models.ExecutionReport]
+	candles    *candleAggregator
+	tickers    *tickerAggregator
+	fees       *feeEngine
+	limits     *limitTracker
 	logger     *slog.Logger
 }
 
-func NewExchange(db repository.Storer, logger *slog.Logger) *Exchange {
+func NewExchange(db repository.Storer, riskChecker risk.Checker, cfg config.Config, logger *slog.Logger) *Exchange {
 	return &Exchange{
-		db:         db,
-		orderBooks: make(map[string]*OrderBook),
-		logger:     logger,
+		db:          db,
+		risk:        riskChecker,
+		cfg:         cfg,
+		orderBooks:  make(map[string]*OrderBook),
+		instruments: make(map[string]instrument),
+		deletions:   make(map[string]*time.Timer),
+		trades:      newFeed[models.Trade](),
+		executions:  newKeyedFeed[int64, models.ExecutionReport](),
+		candles:     newCandleAggregator(db, logger),
+		tickers:     newTickerAggregator(db, logger),
+		fees:        newFeeEngine(db, logger),
+		limits:      newLimitTracker(db, logger),
+		logger:      logger,
 	}
 }
 
-func (e *Exchange) AddOrderBook(symbol string) error {
-	if _, ok := e.orderBooks[symbol]; ok {
+// Start restores the order books and the in-memory market data and launches
+// the background jobs, trading requests are refused until it is done
+func (e *Exchange) Start(ctx context.Context) error {
+	if err := e.recover(); err != nil {
+		e.logger.Error("Failed to recover order books", "error", err)
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := e.journal.close(); err != nil {
+			e.logger.Error("Failed to close journal", "error", err)
+		}
+	}()
+
+	if err := e.reconcileOrderBooks(); err != nil {
+		e.logger.Error("Failed to reconcile order books", "error", err)
+		return err
+	}
+
+	if err := e.syncOrderBooks(); err != nil {
+		e.logger.Error("Failed to sync order books", "error", err)
+		return err
+	}
+
+	if err := e.candles.backfill(); err != nil {
+		e.logger.Error("Failed to backfill candles", "error", err)
+		return err
+	}
+
+	if err := e.tickers.backfill(); err != nil {
+		e.logger.Error("Failed to backfill tickers", "error", err)
+		return err
+	}
+
+	if err := e.fees.backfill(); err != nil {
+		e.logger.Error("Failed to load fee engine", "error", err)
+		return err
+	}
+
+	var books []models.OrderBookL3Snapshot
+	for _, symbol := range e.symbols() {
+		if ob, ok := e.orderBook(symbol); ok {
+			books = append(books, ob.restingOrders())
+		}
+	}
+	if err := e.limits.load(books); err != nil {
+		e.logger.Error("Failed to load user limits", "error", err)
+		return err
+	}
+
+	go e.candles.run(ctx)
+
+	if e.journal != nil && e.cfg.SnapshotDir != "" && e.cfg.SnapshotInterval > 0 {
+		go e.runSnapshots(ctx)
+	}
+
+	e.ready.Store(true)
+	e.logger.Info("Exchange is ready")
+	return nil
+}
+
+func (e *Exchange) AddOrderBook(orderBook models.OrderBook) error {
+	if !e.ready.Load() {
+		return errors.New("Exchange is recovering")
+	}
+
+	inst, err := newInstrument(&orderBook)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := e.orderBook(orderBook.Symbol); ok {
 		e.logger.Error("Order book already exists")
 		return errors.New("Order book already exists")
 	}
-	e.orderBooks[symbol] = NewOrderBook(e.logger)
-	e.logger.Info("OrderBook created successfully", "symbol", symbol)
+
+	if _, err := e.db.CreateOrderBook(orderBook); err != nil {
+		return err
+	}
+
+	if err := e.createOrderBook(orderBook.Symbol); err != nil {
+		return err
+	}
+	e.setInstrument(orderBook.Symbol, inst)
+
+	e.logger.Info(
+		"OrderBook created successfully",
+		"symbol", orderBook.Symbol,
+		"tickSize", orderBook.TickSize,
+		"lotSize", orderBook.LotSize,
+		"minQty", orderBook.MinQty,
+	)
 	return nil
 }
 
-func (e *Exchange) DeleteOrderBook(symbol string) error {
-	if _, ok := e.orderBooks[symbol]; !ok {
-		e.logger.Error("Order book not found")
-		return errors.New("Order book not found")
+// createOrderBook journals the new book before creating it
+func (e *Exchange) createOrderBook(symbol string) error {
+	var cmd = command{
+		Type:   commandCreateBook,
+		Symbol: symbol,
+	}
+	if err := e.journal.append(&cmd); err != nil {
+		e.logger.Error("Failed to journal command", "type", cmd.Type, "error", err)
+		return err
 	}
 
-	e.logger.Info("Order book deleted successfully", "symbol", symbol)
+	e.orderBooksMutex.Lock()
+	e.orderBooks[symbol] = NewOrderBook(symbol, e.logger)
+	e.orderBooksMutex.Unlock()
 	return nil
 }
 
+func (e *Exchange) orderBook(symbol string) (*OrderBook, bool) {
+	e.orderBooksMutex.RLock()
+	defer e.orderBooksMutex.RUnlock()
+
+	ob, ok := e.orderBooks[symbol]
+	return ob, ok
+}
+
+// symbols returns the symbols of every order book in order
+func (e *Exchange) symbols() []string {
+	e.orderBooksMutex.RLock()
+	defer e.orderBooksMutex.RUnlock()
+
+	symbols := make([]string, 0, len(e.orderBooks))
+	for symbol := range e.orderBooks {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// execute journals cmd and applies it to ob, commands of a book are applied
+// one at a time in the order they were journaled so that replay matches exactly
+func (e *Exchange) execute(ob *OrderBook, cmd *command) (*Order, *[]Match, error) {
+	ob.commandMutex.Lock()
+	defer ob.commandMutex.Unlock()
+
+	return e.executeLocked(ob, cmd)
+}
+
+// executePlacement executes a placement and returns holding ob.tradeMutex, taken
+// before commandMutex is let go so that placements of the book store and publish
+// their trades in the order they matched. The caller unlocks ob.tradeMutex.
+// reserve, when set, is called under commandMutex before the order is placed,
+// for reservations that only hold as long as the book does not change
+func (e *Exchange) executePlacement(ob *OrderBook, cmd *command, reserve func() error) (*Order, *[]Match, error) {
+	ob.commandMutex.Lock()
+	defer ob.commandMutex.Unlock()
+
+	if reserve != nil {
+		if err := reserve(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	order, matches, err := e.executeLocked(ob, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	ob.tradeMutex.Lock()
+	return order, matches, nil
+}
+
+// executeLocked is execute for callers holding ob.commandMutex
+func (e *Exchange) executeLocked(ob *OrderBook, cmd *command) (*Order, *[]Match, error) {
+	if ob.deleted {
+		return nil, nil, errors.New("Order book not found")
+	}
+
+	if err := e.journal.append(cmd); err != nil {
+		e.logger.Error("Failed to journal command", "type", cmd.Type, "symbol", cmd.Symbol, "error", err)
+		return nil, nil, err
+	}
+	return ob.apply(*cmd)
+}
+
+// DeleteOrderBook deletes the book right away, or schedules its deletion when
+// req.ScheduledAt is in the future. Resting orders make the deletion fail unless
+// req.CancelOrders is set, then they are canceled and returned
+func (e *Exchange) DeleteOrderBook(req models.DeleteOrderBookReq) ([]models.Order, error) {
+	if !e.ready.Load() {
+		return nil, errors.New("Exchange is recovering")
+	}
+
+	if _, ok := e.orderBook(req.Symbol); !ok {
+		e.logger.Error("Order book not found")
+		return nil, errors.New("Order book not found")
+	}
+
+	if req.ScheduledAt.After(time.Now()) {
+		if err := e.db.ScheduleOrderBookDeletion(req); err != nil {
+			return nil, err
+		}
+		e.scheduleDeletion(req)
+
+		e.logger.Info(
+			"Order book deletion scheduled",
+			"symbol", req.Symbol,
+			"scheduledAt", req.ScheduledAt,
+			"cancelOrders", req.CancelOrders,
+		)
+		return nil, nil
+	}
+
+	return e.deleteOrderBook(req)
+}
+
+func (e *Exchange) ListOrderBooks(includeDeleted bool) ([]models.OrderBook, error) {
+	return e.db.GetOrderBooks(includeDeleted)
+}
+
+// PlaceOrder places the order and returns it along with the counter orders it
+// filled. When input.ClientOrderID was used before only the order placed under it
+// is returned, in its current state, and nothing is placed
 func (e *Exchange) PlaceOrder(input models.PlaceOrderReq) ([]models.Order, error) {
-	ob, ok := e.orderBooks[input.Symbol]
+	if !e.ready.Load() {
+		return nil, errors.New("Exchange is recovering")
+	}
+
+	if input.ClientOrderID != "" {
+		if len(input.ClientOrderID) > maxClientOrderIDLength {
+			return nil, errors.New("Invalid client order ID")
+		}
+
+		orders, found, err := e.placedByClientOrderID(input)
+		if found || err != nil {
+			return orders, err
+		}
+	}
+
+	ob, ok := e.orderBook(input.Symbol)
 	if !ok {
 		e.logger.Error("Order book not found")
 		return nil, errors.New("Order book not found")
@@ -62,95 +311,172 @@ func (e *Exchange) PlaceOrder(input models.PlaceOrderReq) ([]models.Order, error
 		return nil, err
 	}
 
-	orderID, err := e.db.CreateOrder(input)
+	inst, _ := e.instrument(input.Symbol)
+	if err := inst.validate(input.Type, priceDecimal, qtyDecimal); err != nil {
+		e.logger.Error("Order rejected", "symbol", input.Symbol, "error", err)
+		return nil, err
+	}
+
+	orderID, err := e.db.NextOrderID()
 	if err != nil {
 		return nil, err
 	}
 
+	if err := e.limits.admit(orderID, input, priceDecimal, qtyDecimal); err != nil {
+		e.logger.Error("Order rejected", "symbol", input.Symbol, "userID", input.UserID, "error", err)
+		return nil, err
+	}
+
+	// a market bid reserves what sweeping the asks costs, it is reserved when
+	// the order is placed so that the asks can not change in between
+	var reserve = func() error {
+		return e.risk.Reserve(context.Background(), e.reservation(ob, orderID, input, priceDecimal, qtyDecimal))
+	}
+	if !input.IsBid || input.Type != "market" {
+		if err := reserve(); err != nil {
+			e.limits.remove(orderID)
+			e.logger.Error("Order rejected", "symbol", input.Symbol, "userID", input.UserID, "error", err)
+			return nil, err
+		}
+		reserve = nil
+	}
+
+	if err := e.db.CreateOrder(orderID, input); err != nil {
+		e.limits.remove(orderID)
+		e.release(orderID)
+		if err.Error() == "Duplicate client order ID" {
+			// a concurrent retry created the order first
+			orders, _, err := e.placedByClientOrderID(input)
+			return orders, err
+		}
+		return nil, err
+	}
+
+	var resting bool
 	defer func() {
-		if err != nil {
+		if err != nil && !resting {
 			go e.db.SetOrderStatusToError(orderID)
+			e.limits.remove(orderID)
+			e.release(orderID)
+			e.reportReject(models.Order{
+				ID:     orderID,
+				UserID: input.UserID,
+				IsBid:  input.IsBid,
+				Symbol: input.Symbol,
+				Price:  input.Price,
+				Qty:    input.Qty,
+				Status: "error",
+				Type:   input.Type,
+			}, err.Error())
 		}
 	}()
 
-	var (
-		matches *[]Match
-		order   = &Order{
-			ID:        orderID,
-			isBid:     input.IsBid,
-			orderType: input.Type,
-			price:     priceDecimal,
-			qty:       qtyDecimal,
-		}
+	var cmd = command{
+		Type:      commandPlace,
+		Symbol:    input.Symbol,
+		OrderID:   orderID,
+		UserID:    input.UserID,
+		IsBid:     input.IsBid,
+		OrderType: input.Type,
+		Price:     priceDecimal,
+		Qty:       qtyDecimal,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	e.logger.Info(
+		"Placing order",
+		"type", input.Type,
+		"userID", input.UserID,
+		"orderID", orderID,
+		"symbol", input.Symbol,
+		"isBid", input.IsBid,
+		"price", input.Price,
+		"qty", input.Qty,
 	)
 
-	switch order.orderType {
-	case "limit":
-		e.logger.Info(
-			"Placing limit Order",
-			"userID", input.UserID,
-			"orderID", orderID,
-			"symbol", input.Symbol,
-			"isBid", input.IsBid,
-			"price", input.Price,
-			"qty", input.Qty,
-		)
-		matches, err = ob.placeLimitOrder(input.Price, order)
-	case "market":
-		e.logger.Info(
-			"Placing market Order",
-			"userID", input.UserID,
-			"orderID", orderID,
-			"symbol", input.Symbol,
-			"isBid", input.IsBid,
-			"price", input.Price,
-			"qty", input.Qty,
-		)
-		matches, err = ob.placeMarketOrder(order)
-	}
+	order, matches, err := e.executePlacement(ob, &cmd, reserve)
 	if err != nil {
 		return nil, err
 	}
 
-	var addMatchesReq = repository.AddMatchesReq{
-		OrderID:         order.ID,
-		OrderSizeFilled: order.sizeFilled.String(),
+	if matches != nil {
+		for _, match := range *matches {
+			e.limits.fill(orderID, match.qty)
+			e.limits.fill(match.counterOrderID, match.qty)
+		}
 	}
 
+	var (
+		addMatchesReq = repository.AddMatchesReq{
+			OrderID:         order.ID,
+			OrderSizeFilled: order.sizeFilled.String(),
+		}
+		fills []risk.Fill
+	)
+
 	if matches != nil {
 		for _, match := range *matches {
+			makerFee, takerFee := e.fees.fees(input.Symbol, match.counterUserID, input.UserID, match.price, match.qty)
+			fills = append(fills, newFill(orderID, input, match, makerFee, takerFee))
+
 			var newMatch = repository.Match{
 				Qty:                    match.qty.String(),
 				Price:                  match.price.String(),
 				CounterOrderID:         match.counterOrderID,
 				CounterOrderSizeFilled: match.counterOrderSizeFilled.String(),
+				MakerFee:               makerFee.String(),
+				TakerFee:               takerFee.String(),
+				FeeCurrency:            quoteCurrency(input.Symbol),
 			}
 			addMatchesReq.Matches = append(addMatchesReq.Matches, newMatch)
 		}
 	}
 
-	updatedOrders, err := e.db.AddMatches(addMatchesReq)
+	updatedOrders, trades, err := e.db.AddMatches(addMatchesReq)
 	if err != nil {
+		ob.tradeMutex.Unlock()
+		resting = e.unplace(ob, cmd)
 		return nil, err
 	}
 
+	for i, trade := range trades {
+		fills[i].TradeID = trade.ID
+		e.trades.publish(trade)
+		e.candles.record(trade)
+		e.tickers.record(trade)
+
+		match := (*matches)[i]
+		quoteVolume := match.price.Mul(match.qty)
+		e.fees.record(input.UserID, input.Symbol, quoteVolume, trade.ExecutedAt)
+		e.fees.record(match.counterUserID, input.Symbol, quoteVolume, trade.ExecutedAt)
+	}
+	ob.tradeMutex.Unlock()
+
+	e.settle(fills)
+	e.release(closedOrderIDs(updatedOrders)...)
+	e.reportPlacement(updatedOrders, trades)
+
 	e.logger.Info("Order filled successfully", "orderID", orderID)
 	return updatedOrders, nil
 }
 
 func (e *Exchange) CancelOrder(orderID int64) (models.Order, error) {
+	if !e.ready.Load() {
+		return models.Order{}, errors.New("Exchange is recovering")
+	}
+
 	order, err := e.db.GetOrderByOrderID(orderID)
 	if err != nil {
 		return models.Order{}, err
 	}
 
-	ob, ok := e.orderBooks[order.Symbol]
+	ob, ok := e.orderBook(order.Symbol)
 	if !ok {
 		e.logger.Error("Order book not found")
 		return models.Order{}, errors.New("Order book not found")
 	}
 
-	err = ob.cancelLimitOrder(orderID, order.Price, order.IsBid)
+	err = e.cancel(ob, order)
 	if err != nil {
 		return models.Order{}, err
 	}
@@ -159,19 +485,204 @@ func (e *Exchange) CancelOrder(orderID int64) (models.Order, error) {
 	if err != nil {
 		return models.Order{}, err
 	}
+	e.limits.remove(orderID)
+	e.release(orderID)
 
 	order, err = e.db.GetOrderByOrderID(orderID)
 	if err != nil {
 		return models.Order{}, err
 	}
 
+	e.reportCancel("", order)
 	return order, nil
 }
 
-func (e *Exchange) GetCurrentOrders(userID int64) ([]models.Order, error) {
-	return e.db.GetNotFilledOrdersByUser(userID)
+// cancel removes a resting order from its book through the journal
+func (e *Exchange) cancel(ob *OrderBook, order models.Order) error {
+	price, err := decimal.NewFromString(order.Price)
+	if err != nil {
+		e.logger.Error("Error converting price to decimal", "error", err)
+		return err
+	}
+
+	var cmd = command{
+		Type:    commandCancel,
+		Symbol:  order.Symbol,
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		IsBid:   order.IsBid,
+		Price:   price,
+	}
+	_, _, err = e.execute(ob, &cmd)
+	return err
 }
 
-func (e *Exchange) GetOrders(userID int64) ([]models.Order, error) {
-	return e.db.GetOrdersByUser(userID)
+// unplace takes what is left of a placement whose trades could not be stored out
+// of the book, it returns false when the order is no longer there and is left
+// to be rejected. An order still in the book keeps its reservation
+func (e *Exchange) unplace(ob *OrderBook, cmd command) bool {
+	if cmd.OrderType != "limit" {
+		return false
+	}
+
+	err := e.cancel(ob, models.Order{
+		ID:     cmd.OrderID,
+		UserID: cmd.UserID,
+		IsBid:  cmd.IsBid,
+		Symbol: cmd.Symbol,
+		Price:  cmd.Price.String(),
+	})
+	if err == nil {
+		return false
+	}
+
+	switch err.Error() {
+	case "limit not found", "order not found":
+		// the order filled whole
+		return false
+	case "Order book not found":
+		// deleting the book canceled the order and released its reservation
+		return true
+	}
+	e.logger.Error("Failed to remove order from order book", "orderID", cmd.OrderID, "error", err)
+	return true
+}
+
+func (e *Exchange) CancelOrders(filter models.OpenOrdersFilter) ([]models.Order, error) {
+	if !e.ready.Load() {
+		return nil, errors.New("Exchange is recovering")
+	}
+
+	orders, err := e.db.GetOpenOrders(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []int64
+	for _, order := range orders {
+		ob, ok := e.orderBook(order.Symbol)
+		if !ok {
+			e.logger.Warn("Order book not found, skipping order", "orderID", order.ID, "symbol", order.Symbol)
+			continue
+		}
+
+		err = e.cancel(ob, order)
+		if err != nil {
+			e.logger.Warn("Failed to remove order from order book, skipping order", "orderID", order.ID, "error", err)
+			continue
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	if len(orderIDs) == 0 {
+		return nil, nil
+	}
+
+	canceledOrders, err := e.db.SetOrdersStatusToCancel(orderIDs, "")
+	if err != nil {
+		return nil, err
+	}
+	e.limits.remove(orderIDs...)
+	e.release(orderIDs...)
+	e.reportCancel("", canceledOrders...)
+
+	e.logger.Info(
+		"Orders canceled successfully",
+		"userID", filter.UserID,
+		"symbol", filter.Symbol,
+		"count", len(canceledOrders),
+	)
+	return canceledOrders, nil
+}
+
+func (e *Exchange) GetOrderBook(symbol string, depth int, grouping string) (models.OrderBookSnapshot, error) {
+	ob, ok := e.orderBook(symbol)
+	if !ok {
+		e.logger.Error("Order book not found")
+		return models.OrderBookSnapshot{}, errors.New("Order book not found")
+	}
+
+	var groupingDecimal decimal.Decimal
+	if grouping != "" {
+		var err error
+		groupingDecimal, err = decimal.NewFromString(grouping)
+		if err != nil {
+			e.logger.Error("Error converting grouping to decimal", "error", err)
+			return models.OrderBookSnapshot{}, err
+		}
+	}
+
+	return ob.depth(depth, groupingDecimal), nil
+}
+
+func (e *Exchange) GetOrderBookL3(symbol string) (models.OrderBookL3Snapshot, error) {
+	ob, ok := e.orderBook(symbol)
+	if !ok {
+		e.logger.Error("Order book not found")
+		return models.OrderBookL3Snapshot{}, errors.New("Order book not found")
+	}
+
+	return ob.restingOrders(), nil
+}
+
+func (e *Exchange) SubscribeOrderBook(symbol string) (models.OrderBookSnapshot, <-chan models.PriceLevelUpdate, func(), error) {
+	ob, ok := e.orderBook(symbol)
+	if !ok {
+		e.logger.Error("Order book not found")
+		return models.OrderBookSnapshot{}, nil, nil, errors.New("Order book not found")
+	}
+
+	snapshot, updates := ob.subscribe()
+	return snapshot, updates, func() { ob.levelUpdates.unsubscribe(updates) }, nil
+}
+
+func (e *Exchange) SubscribeTrades() (<-chan models.Trade, func()) {
+	trades := e.trades.subscribe(tradesBuffer)
+	return trades, func() { e.trades.unsubscribe(trades) }
+}
+
+func (e *Exchange) GetTrades(symbol string, afterTradeID int64, limit int) ([]models.Trade, error) {
+	return e.db.GetTrades(symbol, afterTradeID, limit)
+}
+
+func (e *Exchange) GetCandles(symbol, interval string, from, to time.Time) ([]models.Candle, error) {
+	return e.candles.candles(symbol, interval, from, to)
+}
+
+func (e *Exchange) SubscribeCandles(symbol, interval string) (<-chan models.Candle, func(), error) {
+	return e.candles.subscribe(symbol, interval)
+}
+
+// GetTickers returns the ticker of symbol, or of every symbol when it is empty
+func (e *Exchange) GetTickers(symbol string) ([]models.Ticker, error) {
+	var symbols []string
+	if symbol != "" {
+		if _, ok := e.orderBook(symbol); !ok {
+			e.logger.Error("Order book not found")
+			return nil, errors.New("Order book not found")
+		}
+		symbols = []string{symbol}
+	} else {
+		symbols = e.symbols()
+	}
+
+	var (
+		now     = time.Now().UTC()
+		tickers []models.Ticker
+	)
+	for _, symbol := range symbols {
+		ob, ok := e.orderBook(symbol)
+		if !ok {
+			continue
+		}
+
+		ticker := e.tickers.ticker(symbol, now)
+		ob.bestPrices(&ticker)
+		tickers = append(tickers, ticker)
+	}
+	return tickers, nil
+}
+
+func (e *Exchange) GetCurrentOrders(userID int64) ([]models.Order, error) {
+	return e.db.GetNotFilledOrdersByUser(userID)
 }
@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// executionsBuffer is how far an execution report subscriber may fall behind before it is dropped
+const executionsBuffer = 1024
+
+func (e *Exchange) SubscribeExecutions(userID int64) (<-chan models.ExecutionReport, func()) {
+	reports := e.executions.subscribe(userID, executionsBuffer)
+	return reports, func() { e.executions.unsubscribe(userID, reports) }
+}
+
+// reportPlacement publishes the reports of a placed order and of every resting order it hit.
+// updatedOrders is the AddMatches result: the placed order first, then one counter order per trade.
+// The placed order is reported as it was after each trade, its last report carries its final state
+func (e *Exchange) reportPlacement(updatedOrders []models.Order, trades []models.Trade) {
+	var (
+		now    = time.Now().UTC()
+		order  = updatedOrders[0]
+		placed = order
+		filled decimal.Decimal
+	)
+	if len(trades) > 0 {
+		placed.SizeFilled, placed.Status, placed.ClosedAt = "0", "filling", sql.NullTime{}
+	}
+
+	e.publishExecution(models.ExecutionReport{
+		ExecType:  models.ExecTypeNew,
+		Order:     placed,
+		CreatedAt: now,
+	})
+
+	for i, trade := range trades {
+		counterOrder := updatedOrders[i+1]
+
+		var (
+			execType = models.ExecTypePartialFill
+			taker    = order
+		)
+		if i < len(trades)-1 {
+			qty, err := decimal.NewFromString(trade.Qty)
+			if err != nil {
+				e.logger.Error("Error converting qty to decimal", "tradeID", trade.ID, "error", err)
+			}
+			filled = filled.Add(qty)
+			taker = placed
+			taker.SizeFilled = filled.String()
+		} else if order.Status == "filled" {
+			execType = models.ExecTypeFill
+		}
+		e.publishExecution(models.ExecutionReport{
+			ExecType:       execType,
+			Order:          taker,
+			TradeID:        trade.ID,
+			LastQty:        trade.Qty,
+			LastPrice:      trade.Price,
+			CounterOrderID: counterOrder.ID,
+			Liquidity:      models.LiquidityTaker,
+			Fee:            trade.TakerFee,
+			FeeCurrency:    trade.FeeCurrency,
+			CreatedAt:      now,
+		})
+
+		execType = models.ExecTypePartialFill
+		if counterOrder.Status == "filled" {
+			execType = models.ExecTypeFill
+		}
+		e.publishExecution(models.ExecutionReport{
+			ExecType:       execType,
+			Order:          counterOrder,
+			TradeID:        trade.ID,
+			LastQty:        trade.Qty,
+			LastPrice:      trade.Price,
+			CounterOrderID: order.ID,
+			Liquidity:      models.LiquidityMaker,
+			Fee:            trade.MakerFee,
+			FeeCurrency:    trade.FeeCurrency,
+			CreatedAt:      now,
+		})
+	}
+}
+
+func (e *Exchange) reportCancel(reason string, orders ...models.Order) {
+	now := time.Now().UTC()
+	for _, order := range orders {
+		e.publishExecution(models.ExecutionReport{
+			ExecType:  models.ExecTypeCanceled,
+			Order:     order,
+			Reason:    reason,
+			CreatedAt: now,
+		})
+	}
+}
+
+func (e *Exchange) reportReject(order models.Order, reason string) {
+	e.publishExecution(models.ExecutionReport{
+		ExecType:  models.ExecTypeRejected,
+		Order:     order,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func (e *Exchange) publishExecution(report models.ExecutionReport) {
+	e.executions.publish(report.Order.UserID, report)
+}
@@ -0,0 +1,53 @@
+package exchange
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportPlacement(t *testing.T) {
+	var (
+		e        = NewExchange(nil, risk.Disabled{}, config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		closedAt = sql.NullTime{Time: time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC), Valid: true}
+		reports  = e.executions.subscribe(1, executionsBuffer)
+	)
+
+	// a market bid for 3 that took three asks of 1
+	e.reportPlacement([]models.Order{
+		{ID: 10, UserID: 1, IsBid: true, Symbol: "BTC/USDT", Qty: "3", SizeFilled: "3", Status: "filled", Type: "market", ClosedAt: closedAt},
+		{ID: 1, UserID: 2, Symbol: "BTC/USDT", Price: "100", Qty: "1", SizeFilled: "1", Status: "filled", Type: "limit", ClosedAt: closedAt},
+		{ID: 2, UserID: 3, Symbol: "BTC/USDT", Price: "101", Qty: "1", SizeFilled: "1", Status: "filled", Type: "limit", ClosedAt: closedAt},
+		{ID: 3, UserID: 4, Symbol: "BTC/USDT", Price: "102", Qty: "2", SizeFilled: "1", Status: "filling", Type: "limit"},
+	}, []models.Trade{
+		{ID: 1, Price: "100", Qty: "1"},
+		{ID: 2, Price: "101", Qty: "1"},
+		{ID: 3, Price: "102", Qty: "1"},
+	})
+
+	for _, want := range []struct {
+		execType   string
+		sizeFilled string
+		status     string
+		closed     bool
+	}{
+		{models.ExecTypeNew, "0", "filling", false},
+		{models.ExecTypePartialFill, "1", "filling", false},
+		{models.ExecTypePartialFill, "2", "filling", false},
+		{models.ExecTypeFill, "3", "filled", true},
+	} {
+		report := <-reports
+		require.Equal(t, want.execType, report.ExecType)
+		require.Equal(t, want.sizeFilled, report.Order.SizeFilled)
+		require.Equal(t, want.status, report.Order.Status)
+		require.Equal(t, want.closed, report.Order.ClosedAt.Valid)
+	}
+	require.Empty(t, reports)
+}
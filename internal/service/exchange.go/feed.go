@@ -0,0 +1,116 @@
+package exchange
+
+import "sync"
+
+// feed fans values out to subscribers without ever blocking the publisher.
+// A subscriber whose buffer is full is dropped and its channel closed,
+// so the matching path is never slowed down by a slow consumer
+type feed[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+}
+
+func newFeed[T any]() *feed[T] {
+	return &feed[T]{
+		subscribers: make(map[chan T]struct{}),
+	}
+}
+
+func (f *feed[T]) subscribe(buffer int) chan T {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan T, buffer)
+	f.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (f *feed[T]) unsubscribe(ch chan T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subscribers[ch]; ok {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (f *feed[T]) publish(value T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- value:
+		default:
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *feed[T]) empty() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers) == 0
+}
+
+// close drops every subscriber
+func (f *feed[T]) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+}
+
+// keyedFeed keeps a separate feed per key, e.g. per user,
+// so subscribers never see values published for other keys
+type keyedFeed[K comparable, T any] struct {
+	mu    sync.Mutex
+	feeds map[K]*feed[T]
+}
+
+func newKeyedFeed[K comparable, T any]() *keyedFeed[K, T] {
+	return &keyedFeed[K, T]{
+		feeds: make(map[K]*feed[T]),
+	}
+}
+
+func (kf *keyedFeed[K, T]) subscribe(key K, buffer int) chan T {
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+
+	f, ok := kf.feeds[key]
+	if !ok {
+		f = newFeed[T]()
+		kf.feeds[key] = f
+	}
+	return f.subscribe(buffer)
+}
+
+func (kf *keyedFeed[K, T]) unsubscribe(key K, ch chan T) {
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+
+	f, ok := kf.feeds[key]
+	if !ok {
+		return
+	}
+
+	f.unsubscribe(ch)
+	if f.empty() {
+		delete(kf.feeds, key)
+	}
+}
+
+func (kf *keyedFeed[K, T]) publish(key K, value T) {
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+
+	if f, ok := kf.feeds[key]; ok {
+		f.publish(value)
+	}
+}
@@ -0,0 +1,267 @@
+package exchange
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// feeVolumeDays is the rolling window the volume of a fee tier is counted over
+	feeVolumeDays = 30
+
+	// feePrecision is the number of decimal places fees are rounded to
+	feePrecision = 8
+)
+
+type feeTier struct {
+	minVolume decimal.Decimal
+	makerRate decimal.Decimal
+	takerRate decimal.Decimal
+}
+
+// newFeeTiers validates the schedule and returns its tiers by ascending volume.
+// The exchange never pays out on a trade: the maker and the taker of a trade may sit in
+// different tiers, so the largest maker rebate is at most the lowest taker rate of the schedule
+func newFeeTiers(schedule models.FeeSchedule) ([]feeTier, error) {
+	var tiers []feeTier
+	for _, tier := range schedule.Tiers {
+		minVolume, err := decimal.NewFromString(tier.MinVolume)
+		if err != nil || minVolume.IsNegative() {
+			return nil, errors.New("Invalid min volume")
+		}
+
+		makerRate, err := decimal.NewFromString(tier.MakerRate)
+		if err != nil {
+			return nil, errors.New("Invalid fee rate")
+		}
+
+		takerRate, err := decimal.NewFromString(tier.TakerRate)
+		if err != nil || takerRate.IsNegative() {
+			return nil, errors.New("Invalid fee rate")
+		}
+
+		tiers = append(tiers, feeTier{minVolume: minVolume, makerRate: makerRate, takerRate: takerRate})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].minVolume.LessThan(tiers[j].minVolume)
+	})
+
+	var minMakerRate, minTakerRate decimal.Decimal
+	for i := range tiers {
+		if i > 0 && tiers[i].minVolume.Equal(tiers[i-1].minVolume) {
+			return nil, errors.New("Duplicate fee tier")
+		}
+		if i == 0 || tiers[i].makerRate.LessThan(minMakerRate) {
+			minMakerRate = tiers[i].makerRate
+		}
+		if i == 0 || tiers[i].takerRate.LessThan(minTakerRate) {
+			minTakerRate = tiers[i].takerRate
+		}
+	}
+	if minMakerRate.Add(minTakerRate).IsNegative() {
+		return nil, errors.New("Invalid fee rate")
+	}
+
+	if len(tiers) > 0 && !tiers[0].minVolume.IsZero() {
+		return nil, errors.New("Fee schedule must start at zero volume")
+	}
+	return tiers, nil
+}
+
+type volumeKey struct {
+	userID int64
+	symbol string
+}
+
+// dayVolume holds the quote volume of one day, a ring of them makes the rolling window
+type dayVolume struct {
+	day    int64
+	volume decimal.Decimal
+}
+
+// feeEngine prices the trades of every symbol by its schedule and the tier
+// the rolling volume of each side puts them in
+type feeEngine struct {
+	mu        sync.RWMutex
+	schedules map[string][]feeTier
+	volumes   map[volumeKey]*[feeVolumeDays]dayVolume
+
+	db     repository.Storer
+	logger *slog.Logger
+}
+
+func newFeeEngine(db repository.Storer, logger *slog.Logger) *feeEngine {
+	return &feeEngine{
+		schedules: make(map[string][]feeTier),
+		volumes:   make(map[volumeKey]*[feeVolumeDays]dayVolume),
+		db:        db,
+		logger:    logger,
+	}
+}
+
+// backfill loads the fee schedules and the volume traded in the window
+func (f *feeEngine) backfill() error {
+	schedules, err := f.db.GetFeeSchedules()
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		tiers, err := newFeeTiers(schedule)
+		if err != nil {
+			f.logger.Error("Invalid fee schedule", "symbol", schedule.Symbol, "error", err)
+			return err
+		}
+
+		f.mu.Lock()
+		f.schedules[schedule.Symbol] = tiers
+		f.mu.Unlock()
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(feeVolumeDays - 1))
+	volumes, err := f.db.GetUserVolumes(since)
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		quoteVolume, err := decimal.NewFromString(volume.Volume)
+		if err != nil {
+			f.logger.Error("Error converting volume to decimal", "userID", volume.UserID, "error", err)
+			return err
+		}
+		f.record(volume.UserID, volume.Symbol, quoteVolume, volume.Day)
+	}
+
+	f.logger.Info("Fee engine loaded", "schedules", len(schedules), "userVolumes", len(volumes))
+	return nil
+}
+
+// record adds quoteVolume traded by the user at time at to the window
+func (f *feeEngine) record(userID int64, symbol string, quoteVolume decimal.Decimal, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var key = volumeKey{userID: userID, symbol: symbol}
+	days, ok := f.volumes[key]
+	if !ok {
+		days = &[feeVolumeDays]dayVolume{}
+		f.volumes[key] = days
+	}
+
+	var (
+		day    = at.Unix() / 86400
+		bucket = &days[day%feeVolumeDays]
+	)
+	if bucket.day > day {
+		// the slot already belongs to a newer day, the volume is older than the window
+		return
+	}
+
+	if bucket.day != day {
+		*bucket = dayVolume{day: day}
+	}
+	bucket.volume = bucket.volume.Add(quoteVolume)
+}
+
+// rates returns the rates of the user's tier, caller holds f.mu
+func (f *feeEngine) rates(userID int64, symbol string, now time.Time) (maker, taker decimal.Decimal) {
+	tiers := f.schedules[symbol]
+	if len(tiers) == 0 {
+		return decimal.Zero, decimal.Zero
+	}
+
+	var volume decimal.Decimal
+	if days, ok := f.volumes[volumeKey{userID: userID, symbol: symbol}]; ok {
+		today := now.Unix() / 86400
+		for _, bucket := range days {
+			if bucket.day > today-feeVolumeDays && bucket.day <= today {
+				volume = volume.Add(bucket.volume)
+			}
+		}
+	}
+
+	var tier = tiers[0]
+	for _, t := range tiers[1:] {
+		if volume.LessThan(t.minVolume) {
+			break
+		}
+		tier = t
+	}
+	return tier.makerRate, tier.takerRate
+}
+
+// fees returns what the maker and the taker of a trade pay in the quote currency
+func (f *feeEngine) fees(symbol string, makerUserID, takerUserID int64, price, qty decimal.Decimal) (makerFee, takerFee decimal.Decimal) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var (
+		now   = time.Now().UTC()
+		value = price.Mul(qty)
+	)
+	makerRate, _ := f.rates(makerUserID, symbol, now)
+	_, takerRate := f.rates(takerUserID, symbol, now)
+
+	return value.Mul(makerRate).Round(feePrecision), value.Mul(takerRate).Round(feePrecision)
+}
+
+// maxRate returns the highest rate the user may pay on symbol, zero when both rates are rebates
+func (f *feeEngine) maxRate(userID int64, symbol string) decimal.Decimal {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	maker, taker := f.rates(userID, symbol, time.Now().UTC())
+	return decimal.Max(decimal.Zero, maker, taker)
+}
+
+func (f *feeEngine) setSchedule(symbol string, tiers []feeTier) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(tiers) == 0 {
+		delete(f.schedules, symbol)
+		return
+	}
+	f.schedules[symbol] = tiers
+}
+
+// quoteCurrency returns the currency prices of symbol are in, USDT for BTC/USDT,
+// symbols are checked when their book is created, see newInstrument
+func quoteCurrency(symbol string) string {
+	_, quote, _ := models.SplitSymbol(symbol)
+	return quote
+}
+
+// SetFeeSchedule replaces the fee tiers of the symbol, no tiers makes it free to trade
+func (e *Exchange) SetFeeSchedule(schedule models.FeeSchedule) error {
+	if _, ok := e.orderBook(schedule.Symbol); !ok {
+		e.logger.Error("Order book not found")
+		return errors.New("Order book not found")
+	}
+
+	tiers, err := newFeeTiers(schedule)
+	if err != nil {
+		return err
+	}
+
+	if err := e.db.SetFeeSchedule(schedule); err != nil {
+		return err
+	}
+	e.fees.setSchedule(schedule.Symbol, tiers)
+
+	e.logger.Info("Fee schedule set", "symbol", schedule.Symbol, "tiers", len(tiers))
+	return nil
+}
+
+func (e *Exchange) GetFeeSchedules() ([]models.FeeSchedule, error) {
+	return e.db.GetFeeSchedules()
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeEngine(t *testing.T) {
+	tiers, err := newFeeTiers(models.FeeSchedule{
+		Symbol: "BTC/USDT",
+		Tiers: []models.FeeTier{
+			{MinVolume: "1000000", MakerRate: "-0.0001", TakerRate: "0.0008"},
+			{MinVolume: "0", MakerRate: "0.001", TakerRate: "0.002"},
+		},
+	})
+	require.NoError(t, err)
+
+	var (
+		fees  = newFeeEngine(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		now   = time.Now().UTC()
+		price = decimal.RequireFromString("10000")
+		qty   = decimal.RequireFromString("0.5")
+	)
+	fees.setSchedule("BTC/USDT", tiers)
+
+	// both sides start in the base tier
+	makerFee, takerFee := fees.fees("BTC/USDT", 1, 2, price, qty)
+	require.Equal(t, "5", makerFee.String())
+	require.Equal(t, "10", takerFee.String())
+
+	// volume older than the window does not count
+	fees.record(1, "BTC/USDT", decimal.RequireFromString("2000000"), now.AddDate(0, 0, -feeVolumeDays))
+	makerFee, _ = fees.fees("BTC/USDT", 1, 2, price, qty)
+	require.Equal(t, "5", makerFee.String())
+
+	// the maker reaches the rebate tier
+	fees.record(1, "BTC/USDT", decimal.RequireFromString("600000"), now.AddDate(0, 0, -10))
+	fees.record(1, "BTC/USDT", decimal.RequireFromString("400000"), now)
+	makerFee, takerFee = fees.fees("BTC/USDT", 1, 2, price, qty)
+	require.Equal(t, "-0.5", makerFee.String())
+	require.Equal(t, "10", takerFee.String())
+
+	// symbols without a schedule trade free
+	makerFee, takerFee = fees.fees("ETH/USDT", 1, 2, price, qty)
+	require.True(t, makerFee.IsZero())
+	require.True(t, takerFee.IsZero())
+}
+
+func TestNewFeeTiers(t *testing.T) {
+	for _, tc := range []struct {
+		tiers []models.FeeTier
+		err   string
+	}{
+		{[]models.FeeTier{{MinVolume: "100", MakerRate: "0", TakerRate: "0.001"}}, "Fee schedule must start at zero volume"},
+		{[]models.FeeTier{{MinVolume: "0", MakerRate: "-0.002", TakerRate: "0.001"}}, "Invalid fee rate"},
+		{[]models.FeeTier{{MinVolume: "0", MakerRate: "0", TakerRate: "-0.001"}}, "Invalid fee rate"},
+		{[]models.FeeTier{{MinVolume: "-1", MakerRate: "0", TakerRate: "0"}}, "Invalid min volume"},
+		// a top tier maker trading with a bottom tier taker would be paid more than the taker pays
+		{[]models.FeeTier{{MinVolume: "0", MakerRate: "0.001", TakerRate: "0.001"}, {MinVolume: "1000000", MakerRate: "-0.002", TakerRate: "0.003"}}, "Invalid fee rate"},
+		{[]models.FeeTier{{MinVolume: "0", MakerRate: "0", TakerRate: "0"}, {MinVolume: "0.0", MakerRate: "0", TakerRate: "0"}}, "Duplicate fee tier"},
+	} {
+		_, err := newFeeTiers(models.FeeSchedule{Symbol: "BTC/USDT", Tiers: tc.tiers})
+		require.EqualError(t, err, tc.err)
+	}
+}
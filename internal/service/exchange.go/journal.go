@@ -0,0 +1,245 @@
+package exchange
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	commandCreateBook = "create_book"
+	commandDeleteBook = "delete_book"
+	commandPlace      = "place"
+	commandCancel     = "cancel"
+	// commandRestore puts an order that was resting before the journal existed
+	// back into its book without matching it
+	commandRestore = "restore"
+)
+
+// journalHeaderSize is the length and the CRC-32 of the payload, both big endian uint32
+const journalHeaderSize = 8
+
+// command is a single engine input, everything needed to apply it again
+// has to be in here as replay never touches the database
+type command struct {
+	Seq        uint64          `json:"seq"`
+	Type       string          `json:"type"`
+	Symbol     string          `json:"symbol"`
+	OrderID    int64           `json:"orderID,omitempty"`
+	UserID     int64           `json:"userID,omitempty"`
+	IsBid      bool            `json:"isBid,omitempty"`
+	OrderType  string          `json:"orderType,omitempty"`
+	Price      decimal.Decimal `json:"price"`
+	Qty        decimal.Decimal `json:"qty"`
+	SizeFilled decimal.Decimal `json:"sizeFilled"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+func (c command) order() *Order {
+	return &Order{
+		ID:         c.OrderID,
+		userID:     c.UserID,
+		isBid:      c.IsBid,
+		orderType:  c.OrderType,
+		price:      c.Price,
+		qty:        c.Qty,
+		sizeFilled: c.SizeFilled,
+		createdAt:  c.CreatedAt,
+	}
+}
+
+// journal is the append-only write-ahead log of every command, a command
+// is durable on disk before it is applied to its order book.
+// A nil journal accepts every command and writes nothing
+type journal struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  uint64
+	size int64
+
+	// broken fails every append once a failed append could not be cut off,
+	// the file no longer ends at size
+	broken error
+}
+
+// openJournal feeds every command stored after offset to apply, cuts off a record
+// torn by a crash and leaves the journal ready for appending. seq is the sequence
+// number of the last command before offset
+func openJournal(path string, offset int64, seq uint64, apply func(command) error) (*journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() < offset {
+		file.Close()
+		return nil, fmt.Errorf("journal is %d bytes long, expected at least %d", info.Size(), offset)
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var lastSeq = seq
+	validSize, err := readJournal(file, func(cmd command) error {
+		lastSeq = cmd.Seq
+		return apply(cmd)
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	validSize += offset
+
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &journal{
+		file: file,
+		seq:  lastSeq,
+		size: validSize,
+	}, nil
+}
+
+// append assigns the next sequence number to cmd and syncs it to disk
+func (j *journal) append(cmd *command) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.broken != nil {
+		return j.broken
+	}
+
+	cmd.Seq = j.seq + 1
+
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, journalHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[journalHeaderSize:], payload)
+
+	if _, err := j.file.Write(record); err != nil {
+		return errors.Join(fmt.Errorf("failed to write journal: %w", err), j.rollback())
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync journal: %w", err), j.rollback())
+	}
+
+	j.seq = cmd.Seq
+	j.size += int64(len(record))
+	return nil
+}
+
+// rollback cuts off what a failed append left after the last record so that
+// the next append starts at a record boundary, caller holds j.mu
+func (j *journal) rollback() error {
+	if err := j.file.Truncate(j.size); err != nil {
+		j.broken = fmt.Errorf("journal is broken, failed to truncate it after a failed append: %w", err)
+		return j.broken
+	}
+
+	if _, err := j.file.Seek(j.size, io.SeekStart); err != nil {
+		j.broken = fmt.Errorf("journal is broken, failed to seek it after a failed append: %w", err)
+		return j.broken
+	}
+	return nil
+}
+
+// position returns the sequence number of the last command and the size of the journal
+func (j *journal) position() (uint64, int64) {
+	if j == nil {
+		return 0, 0
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.seq, j.size
+}
+
+func (j *journal) close() error {
+	if j == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+// readJournal calls fn for every record in order and returns the size of the
+// valid part of the journal. An incomplete record at the end is what a crash
+// in the middle of append leaves behind and is ignored, a checksum mismatch is not
+func readJournal(r io.Reader, fn func(command) error) (int64, error) {
+	var (
+		reader = bufio.NewReader(r)
+		header = make([]byte, journalHeaderSize)
+		offset int64
+	)
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return 0, err
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return 0, err
+		}
+
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return 0, fmt.Errorf("journal record at offset %d is corrupted", offset)
+		}
+
+		var cmd command
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			return 0, fmt.Errorf("journal record at offset %d: %w", offset, err)
+		}
+
+		if err := fn(cmd); err != nil {
+			return 0, err
+		}
+		offset += int64(journalHeaderSize + len(payload))
+	}
+}
@@ -0,0 +1,163 @@
+package exchange
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func journalTestCommands() []command {
+	var (
+		createdAt = time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+		place     = func(orderID int64, isBid bool, orderType, price, qty string) command {
+			return command{
+				Type:      commandPlace,
+				Symbol:    "BTC/USDT",
+				OrderID:   orderID,
+				UserID:    orderID % 3,
+				IsBid:     isBid,
+				OrderType: orderType,
+				Price:     decimal.RequireFromString(price),
+				Qty:       decimal.RequireFromString(qty),
+				CreatedAt: createdAt.Add(time.Duration(orderID) * time.Second),
+			}
+		}
+	)
+
+	return []command{
+		{Type: commandCreateBook, Symbol: "BTC/USDT"},
+		place(1, false, "limit", "101", "1.5"),
+		place(2, false, "limit", "100.0", "2"),
+		place(3, false, "limit", "100", "0.5"),
+		place(4, true, "limit", "99", "3"),
+		{Type: commandCancel, Symbol: "BTC/USDT", OrderID: 2, IsBid: false, Price: decimal.RequireFromString("100")},
+		place(5, true, "limit", "101", "1"),
+		place(6, true, "market", "0", "10"),
+		place(7, false, "market", "0", "2.25"),
+		place(8, false, "limit", "98", "1"),
+	}
+}
+
+func TestReplayMatchesLive(t *testing.T) {
+	var (
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		path   = filepath.Join(t.TempDir(), "commands.journal")
+		live   bytes.Buffer
+	)
+
+	e := NewExchange(nil, risk.Disabled{}, config.Config{}, logger)
+	j, err := openJournal(path, 0, 0, func(command) error { return nil })
+	require.NoError(t, err)
+	e.journal = j
+
+	// the live side journals and applies every command the way the exchange does
+	for _, cmd := range journalTestCommands() {
+		if cmd.Type == commandCreateBook {
+			require.NoError(t, e.createOrderBook(cmd.Symbol))
+			continue
+		}
+
+		ob, ok := e.orderBook(cmd.Symbol)
+		require.True(t, ok)
+		_, matches, _ := e.execute(ob, &cmd)
+		require.NoError(t, writeMatches(&live, cmd, matches))
+	}
+	require.NoError(t, e.journal.close())
+	require.NotEmpty(t, live.String())
+
+	for i := 0; i < 2; i++ {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+
+		var replayed bytes.Buffer
+		require.NoError(t, Replay(file, &replayed, logger))
+		require.NoError(t, file.Close())
+
+		require.Equal(t, live.String(), replayed.String())
+	}
+}
+
+func TestOpenJournalTruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.journal")
+
+	j, err := openJournal(path, 0, 0, func(command) error { return nil })
+	require.NoError(t, err)
+	for _, cmd := range journalTestCommands()[:3] {
+		require.NoError(t, j.append(&cmd))
+	}
+	require.NoError(t, j.close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// a crash in the middle of append leaves part of a record behind
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0, 0, 1, 0, 42})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	var seqs []uint64
+	j, err = openJournal(path, 0, 0, func(cmd command) error {
+		seqs = append(seqs, cmd.Seq)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3}, seqs)
+
+	cmd := journalTestCommands()[3]
+	require.NoError(t, j.append(&cmd))
+	require.Equal(t, uint64(4), cmd.Seq)
+	require.NoError(t, j.close())
+
+	appended, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Greater(t, appended.Size(), info.Size())
+
+	seqs = nil
+	j, err = openJournal(path, 0, 0, func(cmd command) error {
+		seqs = append(seqs, cmd.Seq)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3, 4}, seqs)
+	require.NoError(t, j.close())
+}
+
+func TestFailedAppendIsCutOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.journal")
+
+	j, err := openJournal(path, 0, 0, func(command) error { return nil })
+	require.NoError(t, err)
+	for _, cmd := range journalTestCommands()[:3] {
+		require.NoError(t, j.append(&cmd))
+	}
+
+	// a write that fails partway leaves part of a record behind
+	_, err = j.file.Write([]byte{0, 0, 1, 0, 42})
+	require.NoError(t, err)
+	require.NoError(t, j.rollback())
+
+	cmd := journalTestCommands()[3]
+	require.NoError(t, j.append(&cmd))
+	require.Equal(t, uint64(4), cmd.Seq)
+	require.NoError(t, j.close())
+
+	var seqs []uint64
+	j, err = openJournal(path, 0, 0, func(cmd command) error {
+		seqs = append(seqs, cmd.Seq)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3, 4}, seqs)
+	require.NoError(t, j.close())
+}
@@ -0,0 +1,23 @@
+package exchange
+
+import "github.com/BazaarTrade/OrderMatchingService/internal/models"
+
+// GetBalances returns what the user gained or gave up per asset by trading,
+// an empty asset returns every asset the user traded
+func (e *Exchange) GetBalances(userID int64, asset string) ([]models.Balance, error) {
+	return e.db.GetBalances(userID, asset)
+}
+
+// CheckLedger returns the assets the ledger is out of balance in. Trading only moves
+// assets between accounts so the sum of every asset across all accounts stays zero
+func (e *Exchange) CheckLedger() ([]models.LedgerImbalance, error) {
+	imbalances, err := e.db.GetLedgerImbalances()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, imbalance := range imbalances {
+		e.logger.Error("Ledger is out of balance", "asset", imbalance.Asset, "sum", imbalance.Sum)
+	}
+	return imbalances, nil
+}
@@ -32,6 +32,7 @@ func (l *Limit) matchOrders(order *Order, matches *[]Match) bool {
 		var match = Match{
 			price:          l.price,
 			counterOrderID: bestOrder.ID,
+			counterUserID:  bestOrder.userID,
 		}
 
 		switch order.qty.Cmp(bestOrder.qty) {
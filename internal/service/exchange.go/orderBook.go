@@ -5,11 +5,22 @@ import (
 	"log/slog"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 	"github.com/shopspring/decimal"
 )
 
 type OrderBook struct {
+	symbol string
+
+	// commandMutex keeps commands in journal order, see Exchange.execute
+	commandMutex sync.Mutex
+	deleted      bool //set under commandMutex once the book is deleted
+	// tradeMutex keeps the trades of the book stored and published in the order
+	// they matched, see Exchange.executePlacement
+	tradeMutex sync.Mutex
+
 	askMutex sync.RWMutex
 	bidMutex sync.RWMutex
 
@@ -22,11 +33,19 @@ type OrderBook struct {
 	bidVolume decimal.Decimal
 	askVolume decimal.Decimal
 
+	// sequence is bumped on every change of a limit's totalSize,
+	// guarded by feedMutex which is always taken after bidMutex/askMutex
+	feedMutex    sync.Mutex
+	sequence     uint64
+	levelUpdates *feed[models.PriceLevelUpdate]
+
 	logger *slog.Logger
 }
 
-func NewOrderBook(logger *slog.Logger) *OrderBook {
+func NewOrderBook(symbol string, logger *slog.Logger) *OrderBook {
 	return &OrderBook{
+		symbol:        symbol,
+		levelUpdates:  newFeed[models.PriceLevelUpdate](),
 		bestBidLimits: make([]*Limit, 0),
 		bestAskLimits: make([]*Limit, 0),
 		bidLimits:     make(map[string]*Limit),
@@ -37,24 +56,28 @@ func NewOrderBook(logger *slog.Logger) *OrderBook {
 
 type Order struct {
 	ID         int64
+	userID     int64
 	isBid      bool
 	orderType  string
 	price      decimal.Decimal
 	qty        decimal.Decimal
 	sizeFilled decimal.Decimal
+	createdAt  time.Time
 }
 
 type Match struct {
 	qty                    decimal.Decimal
 	price                  decimal.Decimal
 	counterOrderID         int64
+	counterUserID          int64
 	counterOrderSizeFilled decimal.Decimal
 }
 
-func (ob *OrderBook) placeLimitOrder(price string, order *Order) (*[]Match, error) {
+func (ob *OrderBook) placeLimitOrder(order *Order) (*[]Match, error) {
 	var (
 		limit   *Limit
 		matches *[]Match
+		price   = order.price.String() //limits are keyed by the normalized price so "1.0" and "1" share a limit
 	)
 
 	switch {
@@ -109,6 +132,7 @@ func (ob *OrderBook) placeLimitOrder(price string, order *Order) (*[]Match, erro
 
 	limit.orders = append(limit.orders, order)
 	limit.totalSize = limit.totalSize.Add(order.qty)
+	ob.levelChanged(order.isBid, limit)
 	return matches, nil
 }
 
@@ -121,7 +145,9 @@ func (ob *OrderBook) placeMarketOrder(order *Order) (*[]Match, error) {
 	return matches, nil
 }
 
-func (ob *OrderBook) cancelLimitOrder(orderID int64, orderPrice string, isBid bool) error {
+func (ob *OrderBook) cancelLimitOrder(orderID int64, price decimal.Decimal, isBid bool) error {
+	orderPrice := price.String()
+
 	switch {
 	case isBid:
 		ob.bidMutex.Lock()
@@ -134,9 +160,10 @@ func (ob *OrderBook) cancelLimitOrder(orderID int64, orderPrice string, isBid bo
 		if !limit.removeOrder(orderID) {
 			return errors.New("order not found")
 		}
+		ob.levelChanged(isBid, limit)
 
-		if limit.totalSize.IsZero() {
-			removeEmptyLimits([]string{orderPrice}, &ob.bestBidLimits, ob.bidLimits)
+		if len(limit.orders) == 0 {
+			removeLimit(orderPrice, &ob.bestBidLimits, ob.bidLimits)
 		}
 
 	case !isBid:
@@ -150,6 +177,11 @@ func (ob *OrderBook) cancelLimitOrder(orderID int64, orderPrice string, isBid bo
 		if !limit.removeOrder(orderID) {
 			return errors.New("order not found")
 		}
+		ob.levelChanged(isBid, limit)
+
+		if len(limit.orders) == 0 {
+			removeLimit(orderPrice, &ob.bestAskLimits, ob.askLimits)
+		}
 	}
 	return nil
 }
@@ -176,7 +208,10 @@ func (ob *OrderBook) fillOrder(order *Order) *[]Match {
 				return matches
 			}
 
-			if bestAskLimit.matchOrders(order, matches) {
+			filled := bestAskLimit.matchOrders(order, matches)
+			ob.levelChanged(false, bestAskLimit)
+
+			if filled {
 				if bestAskLimit.totalSize.IsZero() {
 					emptyLimits = append(emptyLimits, bestAskLimit.price.String())
 				}
@@ -201,7 +236,10 @@ func (ob *OrderBook) fillOrder(order *Order) *[]Match {
 				return matches
 			}
 
-			if bestBidLimit.matchOrders(order, matches) {
+			filled := bestBidLimit.matchOrders(order, matches)
+			ob.levelChanged(true, bestBidLimit)
+
+			if filled {
 				if bestBidLimit.totalSize.IsZero() {
 					emptyLimits = append(emptyLimits, bestBidLimit.price.String())
 				}
@@ -236,3 +274,20 @@ func removeEmptyLimits(emptyLimits []string, bestLimits *[]*Limit, limits map[st
 
 	*bestLimits = (*bestLimits)[len(emptyLimits):]
 }
+
+// removeLimit drops a single limit wherever it sits in bestLimits,
+// unlike removeEmptyLimits which expects the emptied limits at the top of the book
+func removeLimit(limitPrice string, bestLimits *[]*Limit, limits map[string]*Limit) {
+	limit, ok := limits[limitPrice]
+	if !ok {
+		return
+	}
+	delete(limits, limitPrice)
+
+	for i, l := range *bestLimits {
+		if l == limit {
+			*bestLimits = append((*bestLimits)[:i], (*bestLimits)[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+)
+
+const (
+	defaultOrdersPageSize = 100
+	maxOrdersPageSize     = 1000
+
+	defaultFillsLimit = 100
+	maxFillsLimit     = 1000
+)
+
+var (
+	orderTypes    = []string{"limit", "market"}
+	orderStatuses = []string{"filling", "filled", "canceled", "error"}
+)
+
+// GetOrders returns a page of the user's orders, newest first, along with the
+// cursor of the next page, nil on the last one. The page size is capped at maxOrdersPageSize
+func (e *Exchange) GetOrders(filter models.OrdersFilter) ([]models.Order, *models.OrderCursor, error) {
+	if filter.Type != "" && !slices.Contains(orderTypes, filter.Type) {
+		return nil, nil, errors.New("Invalid order type")
+	}
+	for _, status := range filter.Statuses {
+		if !slices.Contains(orderStatuses, status) {
+			return nil, nil, errors.New("Invalid order status")
+		}
+	}
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return nil, nil, errors.New("Invalid time range")
+	}
+
+	var pageSize = filter.Limit
+	switch {
+	case pageSize <= 0:
+		pageSize = defaultOrdersPageSize
+	case pageSize > maxOrdersPageSize:
+		pageSize = maxOrdersPageSize
+	}
+
+	// one more order than the page tells whether there is a next page
+	filter.Limit = pageSize + 1
+	orders, err := e.db.GetOrdersByUser(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(orders) <= pageSize {
+		return orders, nil, nil
+	}
+
+	orders = orders[:pageSize]
+	last := orders[pageSize-1]
+	return orders, &models.OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+func (e *Exchange) GetOrder(orderID int64) (models.Order, error) {
+	return e.db.GetOrderByOrderID(orderID)
+}
+
+func (e *Exchange) GetOrderFills(orderID int64) ([]models.Fill, error) {
+	return e.db.GetOrderFills(orderID)
+}
+
+// GetUserFills returns the user's fills executed within [from, to), newest first,
+// up to filter.Limit capped at maxFillsLimit
+func (e *Exchange) GetUserFills(filter models.FillsFilter) ([]models.Fill, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errors.New("Invalid time range")
+	}
+
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultFillsLimit
+	case filter.Limit > maxFillsLimit:
+		filter.Limit = maxFillsLimit
+	}
+	return e.db.GetUserFills(filter)
+}
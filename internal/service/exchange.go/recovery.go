@@ -0,0 +1,282 @@
+package exchange
+
+import (
+	"sort"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// recoverOrderBooks rebuilds the books from the open limit orders stored in the database,
+// the orders come in placement order so every limit gets its original queue.
+// Every book and order is journaled so that the journal alone describes the state from now on
+func (e *Exchange) recoverOrderBooks() error {
+	orders, err := e.db.GetOpenOrders(models.OpenOrdersFilter{})
+	if err != nil {
+		return err
+	}
+
+	var recovered int
+	for _, o := range orders {
+		order, err := restingOrderFromModel(o)
+		if err != nil {
+			e.logger.Error("Failed to recover order", "orderID", o.ID, "error", err)
+			return err
+		}
+
+		if !order.qty.IsPositive() {
+			e.logger.Warn("Open order has nothing left to fill, skipping order", "orderID", o.ID)
+			continue
+		}
+
+		if _, ok := e.orderBooks[o.Symbol]; !ok {
+			if err := e.createOrderBook(o.Symbol); err != nil {
+				return err
+			}
+		}
+
+		var cmd = restoreCommand(o.Symbol, order)
+		if _, _, err := e.execute(e.orderBooks[o.Symbol], &cmd); err != nil {
+			return err
+		}
+		recovered++
+	}
+
+	e.logger.Info("Order books recovered from database", "orderBooks", len(e.orderBooks), "orders", recovered)
+	return nil
+}
+
+// recover rebuilds the books from the latest snapshot and the journal after it,
+// or from the database when there is nothing journaled yet
+func (e *Exchange) recover() error {
+	if e.cfg.JournalPath == "" {
+		e.logger.Warn("Command journal is disabled")
+		return e.recoverOrderBooks()
+	}
+
+	var (
+		offset     int64
+		seq        uint64
+		appliedSeq = make(map[string]uint64)
+	)
+	if e.cfg.SnapshotDir != "" {
+		snap, err := loadLatestSnapshot(e.cfg.SnapshotDir, e.logger)
+		if err != nil {
+			return err
+		}
+
+		if snap != nil {
+			for _, book := range snap.books {
+				e.orderBooks[book.ob.symbol] = book.ob
+				appliedSeq[book.ob.symbol] = book.seq
+			}
+			offset, seq = snap.journalOffset, snap.journalSeq
+		}
+	}
+
+	var replayed int
+	j, err := openJournal(e.cfg.JournalPath, offset, seq, func(cmd command) error {
+		if cmd.Seq <= appliedSeq[cmd.Symbol] {
+			return nil
+		}
+
+		_, _, err := replayCommand(e.orderBooks, cmd, e.logger)
+		if err != nil {
+			// a command that failed live fails the same way on replay
+			e.logger.Debug("Command failed", "seq", cmd.Seq, "type", cmd.Type, "error", err)
+		}
+		replayed++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.journal = j
+
+	if seq == 0 && replayed == 0 {
+		return e.recoverOrderBooks()
+	}
+
+	e.logger.Info("Order books recovered from journal", "orderBooks", len(e.orderBooks), "snapshotSeq", seq, "commands", replayed)
+	return nil
+}
+
+// reconcileOrderBooks makes the books rebuilt from the journal agree with the
+// open orders stored in the database, see Exchange.reconcile
+func (e *Exchange) reconcileOrderBooks() error {
+	if e.cfg.JournalPath == "" {
+		// the books were rebuilt from the database
+		return nil
+	}
+
+	orders, err := e.db.GetOpenOrders(models.OpenOrdersFilter{})
+	if err != nil {
+		return err
+	}
+	return e.reconcile(orders)
+}
+
+// reconcile sides with the stored open orders when the books differ from them:
+// a crash between journaling a placement and storing its trades leaves the books
+// ahead of the database. Orders the database does not have open are canceled
+// out of the books, open orders missing from the books or resting with another
+// qty are restored at the back of their limit. Every change is journaled
+func (e *Exchange) reconcile(stored []models.Order) error {
+	var open = make(map[int64]models.Order)
+	for _, o := range stored {
+		open[o.ID] = o
+	}
+
+	var canceled, restored int
+	for _, symbol := range e.symbols() {
+		ob, _ := e.orderBook(symbol)
+
+		resting := ob.restingOrders()
+		for _, r := range append(resting.Bids, resting.Asks...) {
+			price := decimal.RequireFromString(r.Price)
+
+			if o, ok := open[r.ID]; ok && o.Symbol == symbol && o.IsBid == r.IsBid {
+				order, err := restingOrderFromModel(o)
+				if err != nil {
+					return err
+				}
+				if order.price.Equal(price) && order.qty.Equal(decimal.RequireFromString(r.Qty)) {
+					delete(open, r.ID)
+					continue
+				}
+			}
+
+			var cmd = command{
+				Type:    commandCancel,
+				Symbol:  symbol,
+				OrderID: r.ID,
+				UserID:  r.UserID,
+				IsBid:   r.IsBid,
+				Price:   price,
+			}
+			if _, _, err := e.execute(ob, &cmd); err != nil {
+				return err
+			}
+			canceled++
+		}
+	}
+
+	// what is left of open is missing from the books, restored in placement order
+	for _, o := range stored {
+		if _, ok := open[o.ID]; !ok {
+			continue
+		}
+
+		order, err := restingOrderFromModel(o)
+		if err != nil {
+			e.logger.Error("Failed to recover order", "orderID", o.ID, "error", err)
+			return err
+		}
+
+		if !order.qty.IsPositive() {
+			e.logger.Warn("Open order has nothing left to fill, skipping order", "orderID", o.ID)
+			continue
+		}
+
+		ob, ok := e.orderBook(o.Symbol)
+		if !ok {
+			if err := e.createOrderBook(o.Symbol); err != nil {
+				return err
+			}
+			ob, _ = e.orderBook(o.Symbol)
+		}
+
+		var cmd = restoreCommand(o.Symbol, order)
+		if _, _, err := e.execute(ob, &cmd); err != nil {
+			return err
+		}
+		restored++
+	}
+
+	if canceled > 0 || restored > 0 {
+		e.logger.Warn("Order books reconciled with database", "canceled", canceled, "restored", restored)
+	}
+	return nil
+}
+
+func restoreCommand(symbol string, order *Order) command {
+	return command{
+		Type:       commandRestore,
+		Symbol:     symbol,
+		OrderID:    order.ID,
+		UserID:     order.userID,
+		IsBid:      order.isBid,
+		OrderType:  order.orderType,
+		Price:      order.price,
+		Qty:        order.qty,
+		SizeFilled: order.sizeFilled,
+		CreatedAt:  order.createdAt,
+	}
+}
+
+// restingOrderFromModel converts a stored order to its in-book form
+// with qty being what is still left to fill
+func restingOrderFromModel(o models.Order) (*Order, error) {
+	price, err := decimal.NewFromString(o.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	qty, err := decimal.NewFromString(o.Qty)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeFilled, err := decimal.NewFromString(o.SizeFilled)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		ID:         o.ID,
+		userID:     o.UserID,
+		isBid:      o.IsBid,
+		orderType:  o.Type,
+		price:      price,
+		qty:        qty.Sub(sizeFilled),
+		sizeFilled: sizeFilled,
+		createdAt:  o.CreatedAt,
+	}, nil
+}
+
+// restOrder appends an order that was already resting to its limit without matching it
+func (ob *OrderBook) restOrder(order *Order) {
+	var (
+		price      = order.price.String()
+		limits     = ob.askLimits
+		bestLimits = &ob.bestAskLimits
+		after      = func(l *Limit) bool { return l.price.Cmp(order.price) > 0 }
+	)
+
+	if order.isBid {
+		ob.bidMutex.Lock()
+		defer ob.bidMutex.Unlock()
+		limits, bestLimits = ob.bidLimits, &ob.bestBidLimits
+		after = func(l *Limit) bool { return l.price.Cmp(order.price) < 0 }
+		ob.bidVolume = ob.bidVolume.Add(order.qty)
+	} else {
+		ob.askMutex.Lock()
+		defer ob.askMutex.Unlock()
+		ob.askVolume = ob.askVolume.Add(order.qty)
+	}
+
+	limit, ok := limits[price]
+	if !ok {
+		limit = NewLimit(order.price)
+		limits[price] = limit
+
+		i := sort.Search(len(*bestLimits), func(i int) bool { return after((*bestLimits)[i]) })
+		*bestLimits = append(*bestLimits, nil)
+		copy((*bestLimits)[i+1:], (*bestLimits)[i:])
+		(*bestLimits)[i] = limit
+	}
+
+	limit.orders = append(limit.orders, order)
+	limit.totalSize = limit.totalSize.Add(order.qty)
+	ob.levelChanged(order.isBid, limit)
+}
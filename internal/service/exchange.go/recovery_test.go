@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// restingIDs returns the remaining qty of the orders resting in the book by order ID
+func restingIDs(t *testing.T, e *Exchange, symbol string) map[int64]string {
+	t.Helper()
+
+	ob, ok := e.orderBook(symbol)
+	require.True(t, ok)
+
+	var (
+		resting = ob.restingOrders()
+		ids     = make(map[int64]string)
+	)
+	for _, order := range append(resting.Bids, resting.Asks...) {
+		ids[order.ID] = order.Qty
+	}
+	return ids
+}
+
+func TestReconcileSidesWithDatabase(t *testing.T) {
+	var (
+		logger    = slog.New(slog.NewTextHandler(io.Discard, nil))
+		cfg       = config.Config{JournalPath: filepath.Join(t.TempDir(), "commands.journal")}
+		createdAt = time.Date(2024, 9, 20, 12, 0, 0, 0, time.UTC)
+		stored    = func(id int64, isBid bool, price, qty, sizeFilled string) models.Order {
+			return models.Order{
+				ID: id, UserID: id, IsBid: isBid, Symbol: "BTC/USDT", Price: price, Qty: qty,
+				SizeFilled: sizeFilled, Status: "filling", Type: "limit", CreatedAt: createdAt.Add(time.Duration(id) * time.Second),
+			}
+		}
+	)
+
+	e := NewExchange(nil, risk.Disabled{}, cfg, logger)
+	j, err := openJournal(cfg.JournalPath, 0, 0, func(command) error { return nil })
+	require.NoError(t, err)
+	e.journal = j
+
+	require.NoError(t, e.createOrderBook("BTC/USDT"))
+	ob, _ := e.orderBook("BTC/USDT")
+	for _, cmd := range []command{
+		{Type: commandPlace, Symbol: "BTC/USDT", OrderID: 1, UserID: 1, OrderType: "limit", Price: decimal.RequireFromString("101"), Qty: decimal.RequireFromString("1")},
+		{Type: commandPlace, Symbol: "BTC/USDT", OrderID: 2, UserID: 2, OrderType: "limit", Price: decimal.RequireFromString("100"), Qty: decimal.RequireFromString("2")},
+		{Type: commandPlace, Symbol: "BTC/USDT", OrderID: 3, UserID: 3, OrderType: "limit", Price: decimal.RequireFromString("102"), Qty: decimal.RequireFromString("1")},
+		// the trades of this placement never reached the database
+		{Type: commandPlace, Symbol: "BTC/USDT", OrderID: 4, UserID: 4, IsBid: true, OrderType: "market", Qty: decimal.RequireFromString("1.5")},
+	} {
+		_, _, err := e.execute(ob, &cmd)
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[int64]string{1: "1", 2: "0.5", 3: "1"}, restingIDs(t, e, "BTC/USDT"))
+
+	var open = []models.Order{
+		stored(1, false, "101", "1", "0"),
+		stored(2, false, "100", "2", "0"),
+		// order 3 was canceled in the database, order 5 rests there alone
+		stored(5, true, "99", "3", "1"),
+	}
+	require.NoError(t, e.reconcile(open))
+	require.Equal(t, map[int64]string{1: "1", 2: "2", 5: "2"}, restingIDs(t, e, "BTC/USDT"))
+
+	// the changes are journaled, replaying the journal gives the reconciled books
+	require.NoError(t, e.journal.close())
+	recovered := NewExchange(nil, risk.Disabled{}, cfg, logger)
+	require.NoError(t, recovered.recover())
+	require.Equal(t, restingIDs(t, e, "BTC/USDT"), restingIDs(t, recovered, "BTC/USDT"))
+
+	// books that agree with the database are left alone
+	seq, _ := recovered.journal.position()
+	require.NoError(t, recovered.reconcile(open))
+	reconciledSeq, _ := recovered.journal.position()
+	require.Equal(t, seq, reconciledSeq)
+	require.NoError(t, recovered.journal.close())
+}
@@ -0,0 +1,171 @@
+package exchange
+
+import (
+	"errors"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	orderBookActive  = "active"
+	orderBookDeleted = "deleted"
+)
+
+// instrument is what PlaceOrder checks an order against before it reaches the book
+type instrument struct {
+	status   string
+	tickSize decimal.Decimal
+	lotSize  decimal.Decimal
+	minQty   decimal.Decimal
+}
+
+// newInstrument validates the parameters of orderBook, empty ones are set to zero
+func newInstrument(orderBook *models.OrderBook) (instrument, error) {
+	if _, _, err := models.SplitSymbol(orderBook.Symbol); err != nil {
+		return instrument{}, err
+	}
+
+	var (
+		inst = instrument{status: orderBook.Status}
+		err  error
+	)
+	if inst.status == "" {
+		inst.status = orderBookActive
+	}
+
+	for _, param := range []struct {
+		value *string
+		dst   *decimal.Decimal
+		err   string
+	}{
+		{&orderBook.TickSize, &inst.tickSize, "Invalid tick size"},
+		{&orderBook.LotSize, &inst.lotSize, "Invalid lot size"},
+		{&orderBook.MinQty, &inst.minQty, "Invalid min qty"},
+	} {
+		if *param.value == "" {
+			*param.value = "0"
+		}
+
+		*param.dst, err = decimal.NewFromString(*param.value)
+		if err != nil || param.dst.IsNegative() {
+			return instrument{}, errors.New(param.err)
+		}
+	}
+	return inst, nil
+}
+
+func (i instrument) validate(orderType string, price, qty decimal.Decimal) error {
+	if i.status != orderBookActive {
+		return errors.New("Order book is not active")
+	}
+
+	switch orderType {
+	case "limit":
+		if !price.IsPositive() || (!i.tickSize.IsZero() && !price.Mod(i.tickSize).IsZero()) {
+			return errors.New("Invalid price")
+		}
+	case "market":
+	default:
+		return errors.New("Invalid order type")
+	}
+
+	if !qty.IsPositive() || qty.LessThan(i.minQty) || (!i.lotSize.IsZero() && !qty.Mod(i.lotSize).IsZero()) {
+		return errors.New("Invalid qty")
+	}
+	return nil
+}
+
+func (e *Exchange) instrument(symbol string) (instrument, bool) {
+	e.orderBooksMutex.RLock()
+	defer e.orderBooksMutex.RUnlock()
+
+	inst, ok := e.instruments[symbol]
+	return inst, ok
+}
+
+func (e *Exchange) setInstrument(symbol string, inst instrument) {
+	e.orderBooksMutex.Lock()
+	defer e.orderBooksMutex.Unlock()
+
+	e.instruments[symbol] = inst
+}
+
+// syncOrderBooks reconciles the recovered books with the registry: active symbols
+// without a book get an empty one, deleted symbols lose theirs, pending deletions
+// are scheduled again and books the registry does not know about, such as those
+// created before it existed, are registered with default parameters
+func (e *Exchange) syncOrderBooks() error {
+	orderBooks, err := e.db.GetOrderBooks(true)
+	if err != nil {
+		return err
+	}
+
+	var registered = make(map[string]bool)
+	for _, orderBook := range orderBooks {
+		registered[orderBook.Symbol] = true
+
+		inst, err := newInstrument(&orderBook)
+		if err != nil {
+			e.logger.Error("Invalid order book parameters", "symbol", orderBook.Symbol, "error", err)
+			return err
+		}
+
+		ob, ok := e.orderBook(orderBook.Symbol)
+		if orderBook.Status != orderBookActive {
+			if ok {
+				// the book was deleted in the registry but not in the journal
+				reason := deleteReason(orderBook.DelistReason)
+				canceledOrders, err := e.removeOrderBook(ob, true, func(orderIDs []int64) ([]models.Order, error) {
+					if len(orderIDs) == 0 {
+						return nil, nil
+					}
+					return e.db.SetOrdersStatusToCancel(orderIDs, reason)
+				})
+				if err != nil {
+					return err
+				}
+				e.reportCancel(reason, canceledOrders...)
+				e.logger.Warn("Removed order book deleted in the registry", "symbol", orderBook.Symbol)
+			}
+			continue
+		}
+
+		if !ok {
+			if err := e.createOrderBook(orderBook.Symbol); err != nil {
+				return err
+			}
+		}
+		e.setInstrument(orderBook.Symbol, inst)
+
+		if orderBook.DelistAt.Valid {
+			e.scheduleDeletion(models.DeleteOrderBookReq{
+				Symbol:       orderBook.Symbol,
+				CancelOrders: orderBook.DelistCancelOrders,
+				Reason:       orderBook.DelistReason,
+				ScheduledAt:  orderBook.DelistAt.Time,
+			})
+		}
+	}
+
+	for _, symbol := range e.symbols() {
+		if registered[symbol] {
+			continue
+		}
+
+		var orderBook = models.OrderBook{Symbol: symbol}
+		inst, err := newInstrument(&orderBook)
+		if err != nil {
+			return err
+		}
+
+		if _, err := e.db.CreateOrderBook(orderBook); err != nil {
+			return err
+		}
+		e.setInstrument(symbol, inst)
+		e.logger.Warn("Registered order book missing from the registry", "symbol", symbol)
+	}
+
+	e.logger.Info("Order books synced with the registry", "registered", len(orderBooks))
+	return nil
+}
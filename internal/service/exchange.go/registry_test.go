@@ -0,0 +1,31 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInstrumentSymbol(t *testing.T) {
+	for _, tc := range []struct {
+		symbol string
+		valid  bool
+	}{
+		{"BTC/USDT", true},
+		{"", false},
+		{"BTCUSDT", false},
+		{"/USDT", false},
+		{"BTC/", false},
+		{"BTC/USDT/EUR", false},
+	} {
+		_, err := newInstrument(&models.OrderBook{Symbol: tc.symbol})
+		if tc.valid {
+			require.NoError(t, err, tc.symbol)
+			require.Equal(t, "BTC", baseCurrency(tc.symbol))
+			require.Equal(t, "USDT", quoteCurrency(tc.symbol))
+			continue
+		}
+		require.EqualError(t, err, "Invalid symbol", tc.symbol)
+	}
+}
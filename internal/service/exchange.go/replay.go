@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// apply runs a command against the book, live and on replay alike
+func (ob *OrderBook) apply(cmd command) (*Order, *[]Match, error) {
+	switch cmd.Type {
+	case commandPlace:
+		order := cmd.order()
+
+		switch order.orderType {
+		case "limit":
+			matches, err := ob.placeLimitOrder(order)
+			return order, matches, err
+		case "market":
+			matches, err := ob.placeMarketOrder(order)
+			return order, matches, err
+		}
+		return nil, nil, errors.New("unknown order type")
+
+	case commandCancel:
+		return nil, nil, ob.cancelLimitOrder(cmd.OrderID, cmd.Price, cmd.IsBid)
+
+	case commandRestore:
+		ob.restOrder(cmd.order())
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("unknown command %q", cmd.Type)
+}
+
+func replayCommand(orderBooks map[string]*OrderBook, cmd command, logger *slog.Logger) (*Order, *[]Match, error) {
+	switch cmd.Type {
+	case commandCreateBook:
+		orderBooks[cmd.Symbol] = NewOrderBook(cmd.Symbol, logger)
+		return nil, nil, nil
+	case commandDeleteBook:
+		delete(orderBooks, cmd.Symbol)
+		return nil, nil, nil
+	}
+
+	ob, ok := orderBooks[cmd.Symbol]
+	if !ok {
+		return nil, nil, errors.New("Order book not found")
+	}
+	return ob.apply(cmd)
+}
+
+// Replay applies a journal to fresh order books and writes every match to w.
+// Commands are applied exactly as they were live, so replaying the same
+// journal always produces byte-identical output
+func Replay(r io.Reader, w io.Writer, logger *slog.Logger) error {
+	orderBooks := make(map[string]*OrderBook)
+
+	_, err := readJournal(r, func(cmd command) error {
+		_, matches, err := replayCommand(orderBooks, cmd, logger)
+		if err != nil {
+			// a command that failed live fails the same way on replay
+			logger.Debug("Command failed", "seq", cmd.Seq, "type", cmd.Type, "error", err)
+		}
+		return writeMatches(w, cmd, matches)
+	})
+	return err
+}
+
+// writeMatches writes one line per match:
+// seq symbol orderID counterOrderID price qty counterOrderSizeFilled
+func writeMatches(w io.Writer, cmd command, matches *[]Match) error {
+	if matches == nil {
+		return nil
+	}
+
+	for _, match := range *matches {
+		_, err := fmt.Fprintf(w, "%d %s %d %d %s %s %s\n",
+			cmd.Seq,
+			cmd.Symbol,
+			cmd.OrderID,
+			match.counterOrderID,
+			match.price.String(),
+			match.qty.String(),
+			match.counterOrderSizeFilled.String(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package exchange
+
+import (
+	"context"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/shopspring/decimal"
+)
+
+// reservation is what the order may spend. Asks spend the base asset, bids the quote
+// asset at the limit price plus the highest fee the user may pay. Market bids have
+// no price so they reserve what sweeping the asks costs, the caller holds
+// ob.commandMutex for them, see Exchange.executePlacement
+func (e *Exchange) reservation(ob *OrderBook, orderID int64, input models.PlaceOrderReq, price, qty decimal.Decimal) risk.Reservation {
+	var r = risk.Reservation{
+		OrderID: orderID,
+		UserID:  input.UserID,
+		Asset:   baseCurrency(input.Symbol),
+		Amount:  qty,
+	}
+	if !input.IsBid {
+		return r
+	}
+
+	var value = price.Mul(qty)
+	if input.Type == "market" {
+		value = ob.askCost(qty)
+	}
+	r.Asset = quoteCurrency(input.Symbol)
+	r.Amount = value.Add(value.Mul(e.fees.maxRate(input.UserID, input.Symbol)).RoundUp(feePrecision))
+	return r
+}
+
+// newFill is the match of the placed order seen from the buyer and the seller
+func newFill(orderID int64, input models.PlaceOrderReq, match Match, makerFee, takerFee decimal.Decimal) risk.Fill {
+	var (
+		taker = risk.Party{OrderID: orderID, UserID: input.UserID, Fee: takerFee}
+		maker = risk.Party{OrderID: match.counterOrderID, UserID: match.counterUserID, Fee: makerFee}
+		fill  = risk.Fill{
+			BaseAsset:  baseCurrency(input.Symbol),
+			QuoteAsset: quoteCurrency(input.Symbol),
+			Price:      match.price,
+			Qty:        match.qty,
+			Buyer:      taker,
+			Seller:     maker,
+		}
+	)
+	if !input.IsBid {
+		fill.Buyer, fill.Seller = maker, taker
+	}
+	return fill
+}
+
+// closedOrderIDs returns the orders of a placement that no longer rest in the book
+func closedOrderIDs(orders []models.Order) []int64 {
+	var orderIDs []int64
+	for _, order := range orders {
+		if order.Status != "filling" {
+			orderIDs = append(orderIDs, order.ID)
+		}
+	}
+	return orderIDs
+}
+
+// settle pays both sides of the trades out of their reservations, the trades are
+// stored by then so a failure is logged for the risk checker to reconcile
+func (e *Exchange) settle(fills []risk.Fill) {
+	for _, fill := range fills {
+		if err := e.risk.Settle(context.Background(), fill); err != nil {
+			e.logger.Error("Failed to settle trade", "tradeID", fill.TradeID, "error", err)
+		}
+	}
+}
+
+// release frees what closed orders did not spend, the orders are closed by then
+// so a failure is logged for the risk checker to reconcile
+func (e *Exchange) release(orderIDs ...int64) {
+	for _, orderID := range orderIDs {
+		if err := e.risk.Release(context.Background(), orderID); err != nil {
+			e.logger.Error("Failed to release reservation", "orderID", orderID, "error", err)
+		}
+	}
+}
+
+// baseCurrency returns the currency quantities of symbol are in, BTC for BTC/USDT,
+// symbols are checked when their book is created, see newInstrument
+func baseCurrency(symbol string) string {
+	base, _, _ := models.SplitSymbol(symbol)
+	return base
+}
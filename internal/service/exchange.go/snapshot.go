@@ -0,0 +1,397 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Snapshot file layout, all integers big endian:
+//
+//	magic "OBSS" | version uint16 | journal seq uint64 | journal offset int64 |
+//	created at int64 | book count uint32 | books... | CRC-32 of everything before uint32
+//
+// and every book:
+//
+//	symbol | last applied seq uint64 | update sequence uint64 | bid volume | ask volume |
+//	bid levels | ask levels
+//
+// where levels are a uint32 count of price | total size | orders, and orders are a
+// uint32 count of id int64 | user id int64 | type | price | qty | size filled | created at int64.
+// Strings and decimals are a uint32 length followed by the bytes
+const (
+	snapshotMagic   = "OBSS"
+	snapshotVersion = 1
+	snapshotExt     = ".snap"
+
+	// snapshotsKept older snapshots stay on disk in case the latest one turns out unreadable
+	snapshotsKept = 3
+)
+
+// snapshot is the state of every order book at one point of the journal
+type snapshot struct {
+	// journalSeq and journalOffset are the position of the journal before any book
+	// was captured, replay resumes from there
+	journalSeq    uint64
+	journalOffset int64
+	createdAt     time.Time
+
+	books []bookSnapshot
+}
+
+type bookSnapshot struct {
+	ob *OrderBook
+	// seq is the last journal command applied to the book, everything up to it is in the snapshot
+	seq uint64
+}
+
+// takeSnapshot captures every order book, each one consistently: commands only
+// change a book under its commandMutex, which is held while the book is encoded
+func (e *Exchange) takeSnapshot() ([]byte, uint64) {
+	journalSeq, journalOffset := e.journal.position()
+
+	var (
+		symbols = e.symbols()
+		books   = new(snapshotWriter)
+		count   uint32
+	)
+	for _, symbol := range symbols {
+		ob, ok := e.orderBook(symbol)
+		if !ok {
+			continue
+		}
+
+		ob.commandMutex.Lock()
+		seq, _ := e.journal.position()
+		books.writeBook(ob, seq)
+		ob.commandMutex.Unlock()
+		count++
+	}
+
+	var w = new(snapshotWriter)
+	w.buf.WriteString(snapshotMagic)
+	w.writeUint16(snapshotVersion)
+	w.writeUint64(journalSeq)
+	w.writeUint64(uint64(journalOffset))
+	w.writeUint64(uint64(time.Now().UTC().UnixNano()))
+	w.writeUint32(count)
+	w.buf.Write(books.buf.Bytes())
+	w.writeUint32(crc32.ChecksumIEEE(w.buf.Bytes()))
+
+	return w.buf.Bytes(), journalSeq
+}
+
+// writeSnapshot stores the snapshot next to the older ones and removes those past snapshotsKept.
+// The file is synced under a temporary name first so a crash never leaves a partial snapshot
+func writeSnapshot(dir string, data []byte, journalSeq uint64) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var (
+		path    = filepath.Join(dir, fmt.Sprintf("%020d%s", journalSeq, snapshotExt))
+		tmpPath = path + ".tmp"
+	)
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	paths, err := snapshotPaths(dir)
+	if err != nil {
+		return err
+	}
+
+	for len(paths) > snapshotsKept {
+		if err := os.Remove(paths[len(paths)-1]); err != nil {
+			return err
+		}
+		paths = paths[:len(paths)-1]
+	}
+	return nil
+}
+
+// snapshotPaths returns the snapshot files of dir, newest first
+func snapshotPaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), snapshotExt) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// loadLatestSnapshot returns the newest snapshot of dir that can be read,
+// or nil when there is none
+func loadLatestSnapshot(dir string, logger *slog.Logger) (*snapshot, error) {
+	paths, err := snapshotPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		snap, err := decodeSnapshot(data, logger)
+		if err != nil {
+			logger.Warn("Skipping invalid snapshot", "path", path, "error", err)
+			continue
+		}
+
+		logger.Info("Loaded snapshot", "path", path, "journalSeq", snap.journalSeq, "orderBooks", len(snap.books))
+		return snap, nil
+	}
+	return nil, nil
+}
+
+func decodeSnapshot(data []byte, logger *slog.Logger) (*snapshot, error) {
+	if len(data) < len(snapshotMagic)+2+4 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, errors.New("not a snapshot")
+	}
+
+	var (
+		body     = data[:len(data)-4]
+		checksum = binary.BigEndian.Uint32(data[len(data)-4:])
+	)
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errors.New("snapshot is corrupted")
+	}
+
+	var r = &snapshotReader{r: bytes.NewReader(body[len(snapshotMagic):])}
+	if version := r.readUint16(); version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	var snap = &snapshot{
+		journalSeq:    r.readUint64(),
+		journalOffset: int64(r.readUint64()),
+		createdAt:     time.Unix(0, int64(r.readUint64())).UTC(),
+	}
+
+	count := r.readUint32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		snap.books = append(snap.books, r.readBook(logger))
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return snap, nil
+}
+
+// runSnapshots snapshots the order books every interval for as long as the journal grows
+func (e *Exchange) runSnapshots(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.SnapshotInterval)
+	defer ticker.Stop()
+
+	lastSeq, _ := e.journal.position()
+	for {
+		select {
+		case <-ticker.C:
+			if seq, _ := e.journal.position(); seq == lastSeq {
+				continue
+			}
+
+			data, journalSeq := e.takeSnapshot()
+			if err := writeSnapshot(e.cfg.SnapshotDir, data, journalSeq); err != nil {
+				e.logger.Error("Failed to write snapshot", "error", err)
+				continue
+			}
+			lastSeq = journalSeq
+			e.logger.Info("Snapshot written", "journalSeq", journalSeq, "bytes", len(data))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type snapshotWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *snapshotWriter) writeUint16(v uint16) {
+	w.buf.Write(binary.BigEndian.AppendUint16(nil, v))
+}
+
+func (w *snapshotWriter) writeUint32(v uint32) {
+	w.buf.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (w *snapshotWriter) writeUint64(v uint64) {
+	w.buf.Write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+func (w *snapshotWriter) writeString(s string) {
+	w.writeUint32(uint32(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *snapshotWriter) writeDecimal(d decimal.Decimal) {
+	w.writeString(d.String())
+}
+
+func (w *snapshotWriter) writeBook(ob *OrderBook, seq uint64) {
+	w.writeString(ob.symbol)
+	w.writeUint64(seq)
+	w.writeUint64(ob.sequence)
+	w.writeDecimal(ob.bidVolume)
+	w.writeDecimal(ob.askVolume)
+	w.writeLimits(ob.bestBidLimits)
+	w.writeLimits(ob.bestAskLimits)
+}
+
+func (w *snapshotWriter) writeLimits(limits []*Limit) {
+	w.writeUint32(uint32(len(limits)))
+	for _, limit := range limits {
+		w.writeDecimal(limit.price)
+		w.writeDecimal(limit.totalSize)
+		w.writeUint32(uint32(len(limit.orders)))
+		for _, order := range limit.orders {
+			w.writeUint64(uint64(order.ID))
+			w.writeUint64(uint64(order.userID))
+			w.writeString(order.orderType)
+			w.writeDecimal(order.price)
+			w.writeDecimal(order.qty)
+			w.writeDecimal(order.sizeFilled)
+			w.writeUint64(uint64(order.createdAt.UnixNano()))
+		}
+	}
+}
+
+// snapshotReader keeps the first error and returns zero values after it
+type snapshotReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *snapshotReader) read(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		r.err = fmt.Errorf("snapshot is truncated: %w", err)
+	}
+	return b
+}
+
+func (r *snapshotReader) readUint16() uint16 {
+	return binary.BigEndian.Uint16(r.read(2))
+}
+
+func (r *snapshotReader) readUint32() uint32 {
+	return binary.BigEndian.Uint32(r.read(4))
+}
+
+func (r *snapshotReader) readUint64() uint64 {
+	return binary.BigEndian.Uint64(r.read(8))
+}
+
+func (r *snapshotReader) readString() string {
+	n := r.readUint32()
+	if r.err == nil && int64(n) > int64(r.r.Len()) {
+		r.err = errors.New("snapshot is truncated")
+	}
+	if r.err != nil {
+		return ""
+	}
+	return string(r.read(int(n)))
+}
+
+func (r *snapshotReader) readDecimal() decimal.Decimal {
+	s := r.readString()
+	if r.err != nil {
+		return decimal.Decimal{}
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		r.err = err
+	}
+	return d
+}
+
+func (r *snapshotReader) readBook(logger *slog.Logger) bookSnapshot {
+	ob := NewOrderBook(r.readString(), logger)
+	seq := r.readUint64()
+	ob.sequence = r.readUint64()
+	ob.bidVolume = r.readDecimal()
+	ob.askVolume = r.readDecimal()
+	ob.bestBidLimits = r.readLimits(true, ob.bidLimits)
+	ob.bestAskLimits = r.readLimits(false, ob.askLimits)
+	return bookSnapshot{ob: ob, seq: seq}
+}
+
+func (r *snapshotReader) readLimits(isBid bool, limits map[string]*Limit) []*Limit {
+	var (
+		count      = r.readUint32()
+		bestLimits = make([]*Limit, 0)
+	)
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		limit := NewLimit(r.readDecimal())
+		limit.totalSize = r.readDecimal()
+
+		orders := r.readUint32()
+		for j := uint32(0); j < orders && r.err == nil; j++ {
+			limit.orders = append(limit.orders, &Order{
+				ID:         int64(r.readUint64()),
+				userID:     int64(r.readUint64()),
+				isBid:      isBid,
+				orderType:  r.readString(),
+				price:      r.readDecimal(),
+				qty:        r.readDecimal(),
+				sizeFilled: r.readDecimal(),
+				createdAt:  time.Unix(0, int64(r.readUint64())).UTC(),
+			})
+		}
+
+		limits[limit.price.String()] = limit
+		bestLimits = append(bestLimits, limit)
+	}
+	return bestLimits
+}
@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/config"
+	"github.com/BazaarTrade/OrderMatchingService/internal/risk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverFromSnapshotAndJournalTail(t *testing.T) {
+	var (
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		dir    = t.TempDir()
+		cfg    = config.Config{
+			JournalPath: filepath.Join(dir, "commands.journal"),
+			SnapshotDir: filepath.Join(dir, "snapshots"),
+		}
+		commands = journalTestCommands()
+		live     bytes.Buffer
+	)
+
+	e := NewExchange(nil, risk.Disabled{}, cfg, logger)
+	j, err := openJournal(cfg.JournalPath, 0, 0, func(command) error { return nil })
+	require.NoError(t, err)
+	e.journal = j
+
+	for i, cmd := range commands {
+		if i == 5 {
+			data, journalSeq := e.takeSnapshot()
+			require.NoError(t, writeSnapshot(cfg.SnapshotDir, data, journalSeq))
+		}
+
+		if cmd.Type == commandCreateBook {
+			require.NoError(t, e.createOrderBook(cmd.Symbol))
+			continue
+		}
+
+		ob, ok := e.orderBook(cmd.Symbol)
+		require.True(t, ok)
+		_, matches, _ := e.execute(ob, &cmd)
+		require.NoError(t, writeMatches(&live, cmd, matches))
+	}
+	require.NoError(t, e.journal.close())
+
+	recovered := NewExchange(nil, risk.Disabled{}, cfg, logger)
+	require.NoError(t, recovered.recover())
+	defer recovered.journal.close()
+
+	for _, symbol := range e.symbols() {
+		ob, _ := e.orderBook(symbol)
+		recoveredOB, ok := recovered.orderBook(symbol)
+		require.True(t, ok)
+		require.Equal(t, ob.restingOrders(), recoveredOB.restingOrders())
+	}
+
+	seq, size := e.journal.position()
+	recoveredSeq, recoveredSize := recovered.journal.position()
+	require.Equal(t, seq, recoveredSeq)
+	require.Equal(t, size, recoveredSize)
+
+	// the snapshot and its tail give the same result as the whole journal
+	file, err := os.Open(cfg.JournalPath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var replayed bytes.Buffer
+	require.NoError(t, Replay(file, &replayed, logger))
+	require.Equal(t, live.String(), replayed.String())
+}
+
+func TestCorruptedSnapshotIsSkipped(t *testing.T) {
+	var (
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		dir    = t.TempDir()
+		e      = NewExchange(nil, risk.Disabled{}, config.Config{}, logger)
+	)
+	require.NoError(t, e.createOrderBook("BTC/USDT"))
+
+	data, _ := e.takeSnapshot()
+	require.NoError(t, writeSnapshot(dir, data, 1))
+
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)/2] ^= 0xff
+	require.NoError(t, writeSnapshot(dir, corrupted, 2))
+
+	snap, err := loadLatestSnapshot(dir, logger)
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	require.Len(t, snap.books, 1)
+	require.Equal(t, "BTC/USDT", snap.books[0].ob.symbol)
+}
@@ -0,0 +1,205 @@
+package exchange
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	tickerWindowMinutes = 24 * 60
+
+	tickersBackfillPage = 1000
+)
+
+// minuteBucket holds the trades of one minute, a ring of them makes the rolling 24h window
+type minuteBucket struct {
+	minute      int64
+	open        decimal.Decimal
+	high        decimal.Decimal
+	low         decimal.Decimal
+	volume      decimal.Decimal
+	quoteVolume decimal.Decimal
+	tradeCount  int
+}
+
+type tickerStats struct {
+	lastPrice decimal.Decimal
+	buckets   [tickerWindowMinutes]minuteBucket
+}
+
+// tickerAggregator keeps rolling 24h statistics per symbol updated on every trade
+type tickerAggregator struct {
+	mu    sync.RWMutex
+	stats map[string]*tickerStats
+
+	db     repository.Storer
+	logger *slog.Logger
+}
+
+func newTickerAggregator(db repository.Storer, logger *slog.Logger) *tickerAggregator {
+	return &tickerAggregator{
+		stats:  make(map[string]*tickerStats),
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (a *tickerAggregator) record(trade models.Trade) {
+	price, err := decimal.NewFromString(trade.Price)
+	if err != nil {
+		a.logger.Error("Error converting price to decimal", "tradeID", trade.ID, "error", err)
+		return
+	}
+
+	qty, err := decimal.NewFromString(trade.Qty)
+	if err != nil {
+		a.logger.Error("Error converting qty to decimal", "tradeID", trade.ID, "error", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats, ok := a.stats[trade.Symbol]
+	if !ok {
+		stats = &tickerStats{}
+		a.stats[trade.Symbol] = stats
+	}
+
+	var (
+		minute = trade.ExecutedAt.Unix() / 60
+		bucket = &stats.buckets[minute%tickerWindowMinutes]
+	)
+	if bucket.minute > minute {
+		// the slot already belongs to a newer minute, the trade is older than the window
+		return
+	}
+	stats.lastPrice = price
+
+	if bucket.minute != minute || bucket.tradeCount == 0 {
+		*bucket = minuteBucket{
+			minute: minute,
+			open:   price,
+			high:   price,
+			low:    price,
+		}
+	}
+
+	bucket.high = decimal.Max(bucket.high, price)
+	bucket.low = decimal.Min(bucket.low, price)
+	bucket.volume = bucket.volume.Add(qty)
+	bucket.quoteVolume = bucket.quoteVolume.Add(price.Mul(qty))
+	bucket.tradeCount++
+}
+
+// backfill loads the trades of the last 24h
+func (a *tickerAggregator) backfill() error {
+	var (
+		since        = time.Now().UTC().Add(-tickerWindowMinutes * time.Minute)
+		afterTradeID int64
+	)
+
+	for {
+		trades, err := a.db.GetTradesSince(since, afterTradeID, tickersBackfillPage)
+		if err != nil {
+			return err
+		}
+
+		for _, trade := range trades {
+			a.record(trade)
+			afterTradeID = trade.ID
+		}
+
+		if len(trades) < tickersBackfillPage {
+			return nil
+		}
+	}
+}
+
+// ticker fills the 24h statistics of symbol, best bid and ask are left to the caller
+func (a *tickerAggregator) ticker(symbol string, now time.Time) models.Ticker {
+	var ticker = models.Ticker{Symbol: symbol}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	stats, ok := a.stats[symbol]
+	if !ok {
+		return ticker
+	}
+
+	var (
+		nowMinute   = now.Unix() / 60
+		open        decimal.Decimal
+		openMinute  int64
+		high        decimal.Decimal
+		low         decimal.Decimal
+		volume      decimal.Decimal
+		quoteVolume decimal.Decimal
+		tradeCount  int
+	)
+
+	for _, bucket := range stats.buckets {
+		if bucket.tradeCount == 0 || bucket.minute <= nowMinute-tickerWindowMinutes || bucket.minute > nowMinute {
+			continue
+		}
+
+		if tradeCount == 0 || bucket.minute < openMinute {
+			open, openMinute = bucket.open, bucket.minute
+		}
+		if tradeCount == 0 || bucket.high.GreaterThan(high) {
+			high = bucket.high
+		}
+		if tradeCount == 0 || bucket.low.LessThan(low) {
+			low = bucket.low
+		}
+		volume = volume.Add(bucket.volume)
+		quoteVolume = quoteVolume.Add(bucket.quoteVolume)
+		tradeCount += bucket.tradeCount
+	}
+
+	ticker.LastPrice = stats.lastPrice.String()
+	ticker.TradeCount = tradeCount
+	ticker.Volume = volume.String()
+	ticker.QuoteVolume = quoteVolume.String()
+	if tradeCount == 0 {
+		return ticker
+	}
+
+	priceChange := stats.lastPrice.Sub(open)
+
+	ticker.Open = open.String()
+	ticker.High = high.String()
+	ticker.Low = low.String()
+	ticker.PriceChange = priceChange.String()
+	ticker.PriceChangePercent = priceChange.Div(open).Mul(decimal.NewFromInt(100)).StringFixed(2)
+	ticker.VWAP = quoteVolume.Div(volume).String()
+	return ticker
+}
+
+// bestPrices fills the top of the book of the ticker
+func (ob *OrderBook) bestPrices(ticker *models.Ticker) {
+	ob.bidMutex.RLock()
+	defer ob.bidMutex.RUnlock()
+	ob.askMutex.RLock()
+	defer ob.askMutex.RUnlock()
+
+	for _, limit := range ob.bestBidLimits {
+		if !limit.totalSize.IsZero() {
+			ticker.BestBid, ticker.BestBidSize = limit.price.String(), limit.totalSize.String()
+			break
+		}
+	}
+
+	for _, limit := range ob.bestAskLimits {
+		if !limit.totalSize.IsZero() {
+			ticker.BestAsk, ticker.BestAskSize = limit.price.String(), limit.totalSize.String()
+			break
+		}
+	}
+}
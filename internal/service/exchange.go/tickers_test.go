@@ -0,0 +1,123 @@
+package exchange
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTickerWindow(t *testing.T) {
+	var (
+		now    = time.Date(2024, 1, 2, 12, 0, 30, 0, time.UTC)
+		window = tickerWindowMinutes * time.Minute
+	)
+
+	trade := func(ago time.Duration, price, qty string) models.Trade {
+		return models.Trade{Symbol: "BTC/USDT", Price: price, Qty: qty, ExecutedAt: now.Add(-ago)}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		trades []models.Trade
+		want   models.Ticker
+	}{
+		{
+			name: "no trades",
+			want: models.Ticker{Symbol: "BTC/USDT"},
+		},
+		{
+			name: "trades within the window",
+			trades: []models.Trade{
+				trade(2*time.Hour, "100", "1"),
+				trade(time.Hour, "90", "1"),
+				trade(time.Hour, "120", "2"),
+				trade(0, "110", "1"),
+			},
+			want: models.Ticker{
+				Symbol: "BTC/USDT", LastPrice: "110",
+				Open: "100", High: "120", Low: "90",
+				Volume: "5", QuoteVolume: "540",
+				PriceChange: "10", PriceChangePercent: "10.00", VWAP: "108",
+				TradeCount: 4,
+			},
+		},
+		{
+			name: "trades older than the window are left out",
+			trades: []models.Trade{
+				trade(window+time.Hour, "50", "3"),
+				trade(time.Hour, "100", "1"),
+			},
+			want: models.Ticker{
+				Symbol: "BTC/USDT", LastPrice: "100",
+				Open: "100", High: "100", Low: "100",
+				Volume: "1", QuoteVolume: "100",
+				PriceChange: "0", PriceChangePercent: "0.00", VWAP: "100",
+				TradeCount: 1,
+			},
+		},
+		{
+			name: "a new minute takes over the bucket of the minute a window ago",
+			trades: []models.Trade{
+				trade(window, "50", "3"),
+				trade(0, "100", "1"),
+			},
+			want: models.Ticker{
+				Symbol: "BTC/USDT", LastPrice: "100",
+				Open: "100", High: "100", Low: "100",
+				Volume: "1", QuoteVolume: "100",
+				PriceChange: "0", PriceChangePercent: "0.00", VWAP: "100",
+				TradeCount: 1,
+			},
+		},
+		{
+			name: "a late trade does not overwrite the newer minute of its bucket",
+			trades: []models.Trade{
+				trade(0, "100", "1"),
+				trade(window, "50", "3"),
+			},
+			want: models.Ticker{
+				Symbol: "BTC/USDT", LastPrice: "100",
+				Open: "100", High: "100", Low: "100",
+				Volume: "1", QuoteVolume: "100",
+				PriceChange: "0", PriceChangePercent: "0.00", VWAP: "100",
+				TradeCount: 1,
+			},
+		},
+		{
+			name: "the oldest trade of the window opens it",
+			trades: []models.Trade{
+				trade(window-time.Minute, "80", "1"),
+				trade(window-2*time.Minute, "70", "1"),
+				trade(time.Minute, "100", "1"),
+			},
+			want: models.Ticker{
+				Symbol: "BTC/USDT", LastPrice: "100",
+				Open: "80", High: "100", Low: "70",
+				Volume: "3", QuoteVolume: "250",
+				PriceChange: "20", PriceChangePercent: "25.00", VWAP: "83.3333333333333333",
+				TradeCount: 3,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newTickerAggregator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			for _, trade := range tc.trades {
+				a.record(trade)
+			}
+
+			require.Equal(t, tc.want, a.ticker("BTC/USDT", now))
+		})
+	}
+
+	// the window moves with now, two minutes later the oldest trade has left it
+	a := newTickerAggregator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	a.record(trade(window-time.Minute, "80", "1"))
+	a.record(trade(0, "100", "1"))
+	got := a.ticker("BTC/USDT", now.Add(2*time.Minute))
+	require.Equal(t, 1, got.TradeCount)
+	require.Equal(t, "100", got.Open)
+}
@@ -0,0 +1,316 @@
+package exchange
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/BazaarTrade/OrderMatchingService/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+type limitKey struct {
+	userID int64
+	symbol string
+}
+
+// userLimits is the parsed form of models.UserLimits, zero is no limit
+type userLimits struct {
+	maxOpenOrders      int
+	maxRestingNotional decimal.Decimal
+	maxOrderQty        decimal.Decimal
+}
+
+func newUserLimits(limits models.UserLimits) (userLimits, error) {
+	if limits.UserID < 0 || limits.MaxOpenOrders < 0 {
+		return userLimits{}, errors.New("Invalid limits")
+	}
+
+	var (
+		parsed = userLimits{maxOpenOrders: limits.MaxOpenOrders}
+		err    error
+	)
+	if limits.MaxRestingNotional != "" {
+		parsed.maxRestingNotional, err = decimal.NewFromString(limits.MaxRestingNotional)
+		if err != nil || parsed.maxRestingNotional.IsNegative() {
+			return userLimits{}, errors.New("Invalid limits")
+		}
+	}
+	if limits.MaxOrderQty != "" {
+		parsed.maxOrderQty, err = decimal.NewFromString(limits.MaxOrderQty)
+		if err != nil || parsed.maxOrderQty.IsNegative() {
+			return userLimits{}, errors.New("Invalid limits")
+		}
+	}
+	return parsed, nil
+}
+
+// exposure is what a user has resting in the book of a symbol, or in every
+// book for an empty symbol
+type exposure struct {
+	openOrders  int
+	bidNotional decimal.Decimal
+	askNotional decimal.Decimal
+}
+
+type trackedOrder struct {
+	key   limitKey
+	isBid bool
+	price decimal.Decimal
+	qty   decimal.Decimal //remaining
+}
+
+// limitTracker enforces the user limits against counters of the resting orders,
+// kept in step with the books as orders rest, fill and cancel
+type limitTracker struct {
+	mu        sync.Mutex
+	limits    map[limitKey]userLimits
+	exposures map[limitKey]*exposure
+	orders    map[int64]*trackedOrder
+
+	db     repository.Storer
+	logger *slog.Logger
+}
+
+func newLimitTracker(db repository.Storer, logger *slog.Logger) *limitTracker {
+	return &limitTracker{
+		limits:    make(map[limitKey]userLimits),
+		exposures: make(map[limitKey]*exposure),
+		orders:    make(map[int64]*trackedOrder),
+		db:        db,
+		logger:    logger,
+	}
+}
+
+// load reads the limits and counts the orders resting in the books
+func (t *limitTracker) load(books []models.OrderBookL3Snapshot) error {
+	allLimits, err := t.db.GetUserLimits()
+	if err != nil {
+		return err
+	}
+
+	for _, limits := range allLimits {
+		parsed, err := newUserLimits(limits)
+		if err != nil {
+			t.logger.Error("Invalid user limits", "userID", limits.UserID, "symbol", limits.Symbol, "error", err)
+			return err
+		}
+		t.set(limits.UserID, limits.Symbol, parsed)
+	}
+
+	var count int
+	for _, book := range books {
+		for _, order := range append(book.Bids, book.Asks...) {
+			price, err := decimal.NewFromString(order.Price)
+			if err != nil {
+				return err
+			}
+			qty, err := decimal.NewFromString(order.Qty)
+			if err != nil {
+				return err
+			}
+
+			t.mu.Lock()
+			t.add(order.ID, limitKey{userID: order.UserID, symbol: book.Symbol}, order.IsBid, price, qty)
+			t.mu.Unlock()
+			count++
+		}
+	}
+
+	t.logger.Info("User limits loaded", "limits", len(allLimits), "restingOrders", count)
+	return nil
+}
+
+func (t *limitTracker) set(userID int64, symbol string, limits userLimits) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.limits[limitKey{userID: userID, symbol: symbol}] = limits
+}
+
+// rule returns the most specific limits of the user in symbol and the exposure
+// they are checked against: the user's orders in symbol for limits set for a
+// symbol, the user's orders in every symbol for the others, caller holds t.mu
+func (t *limitTracker) rule(userID int64, symbol string) (userLimits, limitKey) {
+	for _, key := range []limitKey{
+		{userID: userID, symbol: symbol},
+		{userID: userID},
+		{symbol: symbol},
+		{},
+	} {
+		if limits, ok := t.limits[key]; ok {
+			return limits, limitKey{userID: userID, symbol: key.symbol}
+		}
+	}
+	return userLimits{}, limitKey{userID: userID, symbol: symbol}
+}
+
+// exposureKeys are the exposures an order of key counts in: its symbol and the user's total
+func exposureKeys(key limitKey) []limitKey {
+	return []limitKey{key, {userID: key.userID}}
+}
+
+// admit checks the order against the limits of its user and counts a limit order
+// as resting, the caller reports its fills and removes it once it is closed
+func (t *limitTracker) admit(orderID int64, input models.PlaceOrderReq, price, qty decimal.Decimal) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		key           = limitKey{userID: input.UserID, symbol: input.Symbol}
+		limits, scope = t.rule(input.UserID, input.Symbol)
+	)
+
+	if !limits.maxOrderQty.IsZero() && qty.GreaterThan(limits.maxOrderQty) {
+		return errors.New("Max order size exceeded")
+	}
+
+	if input.Type != "limit" {
+		return nil
+	}
+
+	var current = exposure{}
+	if e, ok := t.exposures[scope]; ok {
+		current = *e
+	}
+
+	if limits.maxOpenOrders > 0 && current.openOrders >= limits.maxOpenOrders {
+		return errors.New("Max open orders exceeded")
+	}
+
+	var notional = current.askNotional
+	if input.IsBid {
+		notional = current.bidNotional
+	}
+	if !limits.maxRestingNotional.IsZero() && notional.Add(price.Mul(qty)).GreaterThan(limits.maxRestingNotional) {
+		return errors.New("Max resting notional exceeded")
+	}
+
+	t.add(orderID, key, input.IsBid, price, qty)
+	return nil
+}
+
+// add counts the order as resting, caller holds t.mu
+func (t *limitTracker) add(orderID int64, key limitKey, isBid bool, price, qty decimal.Decimal) {
+	for _, k := range exposureKeys(key) {
+		e, ok := t.exposures[k]
+		if !ok {
+			e = &exposure{}
+			t.exposures[k] = e
+		}
+
+		e.openOrders++
+		if isBid {
+			e.bidNotional = e.bidNotional.Add(price.Mul(qty))
+		} else {
+			e.askNotional = e.askNotional.Add(price.Mul(qty))
+		}
+	}
+	t.orders[orderID] = &trackedOrder{key: key, isBid: isBid, price: price, qty: qty}
+}
+
+// fill takes qty off the order, a filled order no longer counts
+func (t *limitTracker) fill(orderID int64, qty decimal.Decimal) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	order, ok := t.orders[orderID]
+	if !ok {
+		return
+	}
+
+	qty = decimal.Min(qty, order.qty)
+	t.reduce(order, qty)
+	order.qty = order.qty.Sub(qty)
+	if order.qty.IsZero() {
+		t.untrack(orderID, order)
+	}
+}
+
+// remove stops counting closed orders, orders not counted are skipped
+func (t *limitTracker) remove(orderIDs ...int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, orderID := range orderIDs {
+		if order, ok := t.orders[orderID]; ok {
+			t.reduce(order, order.qty)
+			t.untrack(orderID, order)
+		}
+	}
+}
+
+// reduce takes qty of the order off its side's notional, caller holds t.mu
+func (t *limitTracker) reduce(order *trackedOrder, qty decimal.Decimal) {
+	for _, key := range exposureKeys(order.key) {
+		e := t.exposures[key]
+		if order.isBid {
+			e.bidNotional = e.bidNotional.Sub(order.price.Mul(qty))
+		} else {
+			e.askNotional = e.askNotional.Sub(order.price.Mul(qty))
+		}
+	}
+}
+
+// untrack drops the order from the counters, caller holds t.mu
+func (t *limitTracker) untrack(orderID int64, order *trackedOrder) {
+	delete(t.orders, orderID)
+
+	for _, key := range exposureKeys(order.key) {
+		e := t.exposures[key]
+		e.openOrders--
+		if e.openOrders == 0 {
+			delete(t.exposures, key)
+		}
+	}
+}
+
+// SetUserLimits replaces the limits of limits.UserID in limits.Symbol, orders
+// already resting are kept even when they exceed the new limits
+func (e *Exchange) SetUserLimits(limits models.UserLimits) error {
+	if limits.Symbol != "" {
+		if _, ok := e.orderBook(limits.Symbol); !ok {
+			e.logger.Error("Order book not found")
+			return errors.New("Order book not found")
+		}
+	}
+
+	parsed, err := newUserLimits(limits)
+	if err != nil {
+		return err
+	}
+
+	limits.MaxRestingNotional = parsed.maxRestingNotional.String()
+	limits.MaxOrderQty = parsed.maxOrderQty.String()
+	if err := e.db.SetUserLimits(limits); err != nil {
+		return err
+	}
+	e.limits.set(limits.UserID, limits.Symbol, parsed)
+
+	e.logger.Info(
+		"User limits set",
+		"userID", limits.UserID,
+		"symbol", limits.Symbol,
+		"maxOpenOrders", limits.MaxOpenOrders,
+		"maxRestingNotional", limits.MaxRestingNotional,
+		"maxOrderQty", limits.MaxOrderQty,
+	)
+	return nil
+}
+
+// GetUserLimits returns the limits set for the user along with the limits of every user
+func (e *Exchange) GetUserLimits(userID int64) ([]models.UserLimits, error) {
+	allLimits, err := e.db.GetUserLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	var userLimits []models.UserLimits
+	for _, limits := range allLimits {
+		if limits.UserID == userID || limits.UserID == 0 {
+			userLimits = append(userLimits, limits)
+		}
+	}
+	return userLimits, nil
+}
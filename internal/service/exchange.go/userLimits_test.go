@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/BazaarTrade/OrderMatchingService/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitTracker(t *testing.T) {
+	var (
+		tracker = newLimitTracker(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		price   = decimal.RequireFromString("100")
+		bid     = models.PlaceOrderReq{UserID: 1, IsBid: true, Symbol: "BTC/USDT", Type: "limit"}
+	)
+
+	defaults, err := newUserLimits(models.UserLimits{MaxOpenOrders: 2, MaxOrderQty: "10"})
+	require.NoError(t, err)
+	tracker.set(0, "", defaults)
+
+	userLimits, err := newUserLimits(models.UserLimits{UserID: 1, Symbol: "BTC/USDT", MaxOpenOrders: 2, MaxRestingNotional: "1000"})
+	require.NoError(t, err)
+	tracker.set(1, "BTC/USDT", userLimits)
+
+	// the user's own limits replace the defaults as a whole
+	require.NoError(t, tracker.admit(1, bid, price, decimal.RequireFromString("6")))
+	require.EqualError(t, tracker.admit(2, bid, price, decimal.RequireFromString("5")), "Max resting notional exceeded")
+
+	// fills free up notional, the other side is counted apart
+	tracker.fill(1, decimal.RequireFromString("2"))
+	require.NoError(t, tracker.admit(2, bid, price, decimal.RequireFromString("5")))
+
+	ask := bid
+	ask.IsBid = false
+	require.EqualError(t, tracker.admit(3, ask, price, decimal.RequireFromString("1")), "Max open orders exceeded")
+
+	// canceling and filling orders closes them
+	tracker.remove(1)
+	tracker.fill(2, decimal.RequireFromString("5"))
+	require.Empty(t, tracker.exposures)
+	require.NoError(t, tracker.admit(3, ask, price, decimal.RequireFromString("1")))
+
+	// other users fall back to the defaults, market orders are only checked for size
+	other := models.PlaceOrderReq{UserID: 2, IsBid: true, Symbol: "BTC/USDT", Type: "market"}
+	require.EqualError(t, tracker.admit(4, other, price, decimal.RequireFromString("11")), "Max order size exceeded")
+	require.NoError(t, tracker.admit(4, other, price, decimal.RequireFromString("10")))
+	require.NotContains(t, tracker.orders, int64(4))
+}
+
+func TestUserWideLimits(t *testing.T) {
+	var (
+		tracker = newLimitTracker(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		price   = decimal.RequireFromString("100")
+		qty     = decimal.RequireFromString("1")
+		order   = func(symbol string) models.PlaceOrderReq {
+			return models.PlaceOrderReq{UserID: 1, IsBid: true, Symbol: symbol, Type: "limit"}
+		}
+	)
+
+	userLimits, err := newUserLimits(models.UserLimits{UserID: 1, MaxOpenOrders: 2, MaxRestingNotional: "250"})
+	require.NoError(t, err)
+	tracker.set(1, "", userLimits)
+
+	symbolLimits, err := newUserLimits(models.UserLimits{UserID: 1, Symbol: "SOL/USDT", MaxOpenOrders: 5})
+	require.NoError(t, err)
+	tracker.set(1, "SOL/USDT", symbolLimits)
+
+	// a limit set without a symbol counts the orders of every symbol
+	require.NoError(t, tracker.admit(1, order("BTC/USDT"), price, qty))
+	require.NoError(t, tracker.admit(2, order("ETH/USDT"), price, qty))
+	require.EqualError(t, tracker.admit(3, order("XRP/USDT"), price, qty), "Max open orders exceeded")
+
+	tracker.remove(2)
+	require.EqualError(t, tracker.admit(3, order("ETH/USDT"), price, decimal.RequireFromString("2")), "Max resting notional exceeded")
+
+	// a symbol with limits of its own is checked against its orders alone
+	require.NoError(t, tracker.admit(4, order("SOL/USDT"), price, qty))
+	require.NoError(t, tracker.admit(5, order("SOL/USDT"), price, qty))
+	require.Equal(t, 3, tracker.exposures[limitKey{userID: 1}].openOrders)
+	require.Equal(t, 2, tracker.exposures[limitKey{userID: 1, symbol: "SOL/USDT"}].openOrders)
+
+	tracker.remove(1, 4, 5)
+	require.Empty(t, tracker.exposures)
+}
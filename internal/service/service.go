@@ -1,16 +1,64 @@
 package service
 
 import (
+	"time"
+
 	"github.com/BazaarTrade/OrderMatchingService/internal/models"
 )
 
 type Exchanger interface {
-	AddOrderBook(symbol string) error
-	DeleteOrderBook(symbol string) error
+	AddOrderBook(orderBook models.OrderBook) error
+	// DeleteOrderBook returns the resting orders it canceled, nothing when the deletion is scheduled
+	DeleteOrderBook(req models.DeleteOrderBookReq) ([]models.Order, error)
+	ListOrderBooks(includeDeleted bool) ([]models.OrderBook, error)
+	// SetFeeSchedule replaces the fee tiers of the symbol, no tiers makes it free to trade
+	SetFeeSchedule(schedule models.FeeSchedule) error
+	GetFeeSchedules() ([]models.FeeSchedule, error)
+
+	// GetBalances returns the user's ledger balance per asset, an empty asset returns every asset
+	GetBalances(userID int64, asset string) ([]models.Balance, error)
+	// CheckLedger returns the assets whose ledger entries do not sum to zero
+	CheckLedger() ([]models.LedgerImbalance, error)
+
+	// SetUserLimits replaces the limits of limits.UserID in limits.Symbol
+	SetUserLimits(limits models.UserLimits) error
+	// GetUserLimits returns the limits set for the user along with the limits of every user
+	GetUserLimits(userID int64) ([]models.UserLimits, error)
+	GetOrderBook(symbol string, depth int, grouping string) (models.OrderBookSnapshot, error)
+	GetOrderBookL3(symbol string) (models.OrderBookL3Snapshot, error)
+	// SubscribeOrderBook returns the full book followed by level updates starting
+	// right after snapshot.Sequence, the channel is closed if the subscriber lags behind
+	SubscribeOrderBook(symbol string) (models.OrderBookSnapshot, <-chan models.PriceLevelUpdate, func(), error)
 
+	// PlaceOrder is idempotent per order.ClientOrderID, a retry returns the order placed first
 	PlaceOrder(order models.PlaceOrderReq) ([]models.Order, error)
 	CancelOrder(orderID int64) (models.Order, error)
+	CancelOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error)
+	CancelOrders(filter models.OpenOrdersFilter) ([]models.Order, error)
+
+	// GetOrders returns a page of the user's orders, newest first, and the cursor of the next page
+	GetOrders(filter models.OrdersFilter) ([]models.Order, *models.OrderCursor, error)
+	GetOrderByClientOrderID(userID int64, clientOrderID string) (models.Order, error)
+	GetOrder(orderID int64) (models.Order, error)
+	// GetOrderFills returns the fills of the order, oldest first
+	GetOrderFills(orderID int64) ([]models.Fill, error)
+	// GetUserFills returns the user's fills executed within [from, to), newest first
+	GetUserFills(filter models.FillsFilter) ([]models.Fill, error)
+
+	// SubscribeTrades streams executions of every symbol as they happen,
+	// the channel is closed if the subscriber lags behind
+	SubscribeTrades() (<-chan models.Trade, func())
+	GetTrades(symbol string, afterTradeID int64, limit int) ([]models.Trade, error)
+	// SubscribeExecutions streams execution reports of the user's orders,
+	// the channel is closed if the subscriber lags behind
+	SubscribeExecutions(userID int64) (<-chan models.ExecutionReport, func())
 
-	GetOrders(userID int64) ([]models.Order, error)
+	// GetCandles returns the candles opened within [from, to), including the open one
+	GetCandles(symbol, interval string, from, to time.Time) ([]models.Candle, error)
+	// SubscribeCandles streams every update of the open candle and the candle closing,
+	// the channel is closed if the subscriber lags behind
+	SubscribeCandles(symbol, interval string) (<-chan models.Candle, func(), error)
+	// GetTickers returns the ticker of symbol, or of every symbol when it is empty
+	GetTickers(symbol string) ([]models.Ticker, error)
 	GetCurrentOrders(userID int64) ([]models.Order, error)
 }
@@ -0,0 +1,7 @@
+DROP INDEX matches_symbol_id_idx;
+
+ALTER TABLE matches
+    DROP COLUMN id,
+    DROP COLUMN symbol,
+    DROP COLUMN isBid,
+    DROP COLUMN createdAt;
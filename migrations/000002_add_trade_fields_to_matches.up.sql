@@ -0,0 +1,20 @@
+ALTER TABLE matches
+    ADD COLUMN id BIGSERIAL,
+    ADD COLUMN symbol VARCHAR,
+    ADD COLUMN isBid BOOLEAN,
+    ADD COLUMN createdAt TIMESTAMP;
+
+-- a match is stored with the order that took liquidity, it matched when it was placed
+UPDATE matches
+SET symbol = orders.symbol, isBid = orders.isBid, createdAt = orders.createdAt
+FROM orders
+WHERE orders.id = matches.orderID;
+
+ALTER TABLE matches
+    ALTER COLUMN symbol SET NOT NULL,
+    ALTER COLUMN isBid SET NOT NULL,
+    ALTER COLUMN createdAt SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN createdAt SET NOT NULL,
+    ADD CONSTRAINT matches_id_key UNIQUE (id);
+
+CREATE INDEX matches_symbol_id_idx ON matches (symbol, id);
@@ -0,0 +1,3 @@
+DROP INDEX matches_createdAt_idx;
+
+DROP TABLE candles;
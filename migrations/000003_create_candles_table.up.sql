@@ -0,0 +1,15 @@
+CREATE TABLE candles (
+    symbol VARCHAR NOT NULL,
+    period VARCHAR NOT NULL,
+    openTime TIMESTAMP NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    quoteVolume NUMERIC NOT NULL,
+    tradeCount INTEGER NOT NULL,
+    PRIMARY KEY (symbol, period, openTime)
+);
+
+CREATE INDEX matches_createdAt_idx ON matches (createdAt);
@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP CONSTRAINT orders_symbol_fkey;
+
+DROP TABLE orderBooks;
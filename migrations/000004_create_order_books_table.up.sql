@@ -0,0 +1,15 @@
+CREATE TABLE orderBooks (
+    symbol VARCHAR PRIMARY KEY,
+    status VARCHAR NOT NULL DEFAULT 'active',
+    tickSize NUMERIC NOT NULL DEFAULT 0,
+    lotSize NUMERIC NOT NULL DEFAULT 0,
+    minQty NUMERIC NOT NULL DEFAULT 0,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deletedAt TIMESTAMP
+);
+
+INSERT INTO orderBooks (symbol)
+SELECT DISTINCT symbol FROM orders;
+
+ALTER TABLE orders
+ADD CONSTRAINT orders_symbol_fkey FOREIGN KEY (symbol) REFERENCES orderBooks(symbol);
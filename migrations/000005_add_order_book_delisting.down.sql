@@ -0,0 +1,6 @@
+ALTER TABLE orderBooks
+DROP COLUMN delistReason,
+DROP COLUMN delistCancelOrders,
+DROP COLUMN delistAt;
+
+ALTER TABLE orders DROP COLUMN cancelReason;
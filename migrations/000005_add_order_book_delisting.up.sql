@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN cancelReason VARCHAR;
+
+ALTER TABLE orderBooks
+ADD COLUMN delistAt TIMESTAMP,
+ADD COLUMN delistCancelOrders BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN delistReason VARCHAR NOT NULL DEFAULT '';
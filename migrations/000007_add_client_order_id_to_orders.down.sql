@@ -0,0 +1,3 @@
+DROP INDEX orders_user_client_order_id_idx;
+
+ALTER TABLE orders DROP COLUMN clientOrderID;
@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN clientOrderID VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX orders_user_client_order_id_idx ON orders (userID, clientOrderID)
+WHERE clientOrderID <> '';
@@ -0,0 +1,23 @@
+CREATE OR REPLACE FUNCTION update_order_status()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sizeFilled <> OLD.sizeFilled AND NEW.qty = NEW.sizeFilled THEN
+        NEW.status := 'filled';
+        NEW.closedAt := CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE matches
+    ALTER COLUMN qty TYPE VARCHAR USING qty::VARCHAR,
+    ALTER COLUMN price TYPE VARCHAR USING price::VARCHAR;
+
+ALTER TABLE orders ALTER COLUMN sizeFilled DROP DEFAULT;
+
+ALTER TABLE orders
+    ALTER COLUMN price TYPE VARCHAR USING price::VARCHAR,
+    ALTER COLUMN qty TYPE VARCHAR USING qty::VARCHAR,
+    ALTER COLUMN sizeFilled TYPE VARCHAR USING sizeFilled::VARCHAR;
+
+ALTER TABLE orders ALTER COLUMN sizeFilled SET DEFAULT '0';
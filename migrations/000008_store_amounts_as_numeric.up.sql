@@ -0,0 +1,28 @@
+ALTER TABLE orders ALTER COLUMN sizeFilled DROP DEFAULT;
+
+ALTER TABLE orders
+    ALTER COLUMN price TYPE NUMERIC USING COALESCE(NULLIF(TRIM(price), ''), '0')::NUMERIC,
+    ALTER COLUMN qty TYPE NUMERIC USING TRIM(qty)::NUMERIC,
+    ALTER COLUMN sizeFilled TYPE NUMERIC USING COALESCE(NULLIF(TRIM(sizeFilled), ''), '0')::NUMERIC;
+
+ALTER TABLE orders ALTER COLUMN sizeFilled SET DEFAULT 0;
+
+ALTER TABLE matches
+    ALTER COLUMN qty TYPE NUMERIC USING TRIM(qty)::NUMERIC,
+    ALTER COLUMN price TYPE NUMERIC USING TRIM(price)::NUMERIC;
+
+CREATE OR REPLACE FUNCTION update_order_status()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sizeFilled <> OLD.sizeFilled AND NEW.sizeFilled >= NEW.qty THEN
+        NEW.status := 'filled';
+        NEW.closedAt := CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- orders the string comparison missed, e.g. qty '1.0' filled with sizeFilled '1'
+UPDATE orders
+SET status = 'filled', closedAt = CURRENT_TIMESTAMP
+WHERE status = 'filling' AND sizeFilled >= qty;
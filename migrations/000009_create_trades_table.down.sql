@@ -0,0 +1,26 @@
+CREATE TABLE matches (
+    orderID INTEGER NOT NULL REFERENCES orders(id),
+    orderIDCounter INTEGER NOT NULL REFERENCES orders(id),
+    qty NUMERIC NOT NULL,
+    price NUMERIC NOT NULL,
+    id BIGSERIAL,
+    symbol VARCHAR NOT NULL,
+    isBid BOOLEAN NOT NULL,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (orderID, orderIDCounter),
+    CONSTRAINT matches_id_key UNIQUE (id)
+);
+
+-- matches keeps one fill per pair of orders, later fills of a pair are lost
+INSERT INTO matches (orderID, orderIDCounter, qty, price, id, symbol, isBid, createdAt)
+SELECT takerOrderID, makerOrderID, qty, price, id, symbol, isBid, executedAt
+FROM trades
+ORDER BY id
+ON CONFLICT DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('matches', 'id'), COALESCE((SELECT MAX(id) FROM matches), 0) + 1, false);
+
+CREATE INDEX matches_symbol_id_idx ON matches (symbol, id);
+CREATE INDEX matches_createdAt_idx ON matches (createdAt);
+
+DROP TABLE trades;
@@ -0,0 +1,28 @@
+CREATE TABLE trades (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR NOT NULL REFERENCES orderBooks(symbol),
+    makerOrderID INTEGER NOT NULL REFERENCES orders(id),
+    takerOrderID INTEGER NOT NULL REFERENCES orders(id),
+    isBid BOOLEAN NOT NULL,
+    price NUMERIC NOT NULL,
+    qty NUMERIC NOT NULL,
+    makerFee NUMERIC NOT NULL DEFAULT 0,
+    takerFee NUMERIC NOT NULL DEFAULT 0,
+    executedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON COLUMN trades.isBid IS 'side of the taker, the order that crossed the spread';
+
+INSERT INTO trades (id, symbol, makerOrderID, takerOrderID, isBid, price, qty, executedAt)
+SELECT id, symbol, orderIDCounter, orderID, isBid, price, qty, createdAt
+FROM matches
+ORDER BY id;
+
+SELECT setval(pg_get_serial_sequence('trades', 'id'), COALESCE((SELECT MAX(id) FROM trades), 0) + 1, false);
+
+CREATE INDEX trades_symbol_id_idx ON trades (symbol, id);
+CREATE INDEX trades_executedAt_idx ON trades (executedAt);
+CREATE INDEX trades_makerOrderID_idx ON trades (makerOrderID);
+CREATE INDEX trades_takerOrderID_idx ON trades (takerOrderID);
+
+DROP TABLE matches;
@@ -0,0 +1,3 @@
+ALTER TABLE trades DROP COLUMN feeCurrency;
+
+DROP TABLE feeTiers;
@@ -0,0 +1,10 @@
+CREATE TABLE feeTiers (
+    symbol VARCHAR NOT NULL REFERENCES orderBooks(symbol),
+    minVolume NUMERIC NOT NULL CHECK (minVolume >= 0),
+    makerRate NUMERIC NOT NULL,
+    takerRate NUMERIC NOT NULL CHECK (takerRate >= 0),
+    PRIMARY KEY (symbol, minVolume),
+    CHECK (makerRate + takerRate >= 0)
+);
+
+ALTER TABLE trades ADD COLUMN feeCurrency VARCHAR NOT NULL DEFAULT '';
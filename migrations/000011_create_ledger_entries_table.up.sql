@@ -0,0 +1,13 @@
+CREATE TABLE ledgerEntries (
+    id BIGSERIAL PRIMARY KEY,
+    tradeID BIGINT NOT NULL REFERENCES trades(id),
+    userID INTEGER NOT NULL,
+    asset VARCHAR NOT NULL,
+    amount NUMERIC NOT NULL,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON COLUMN ledgerEntries.userID IS '0 is the fee account';
+
+CREATE INDEX ledger_entries_user_asset_idx ON ledgerEntries (userID, asset);
+CREATE INDEX ledger_entries_trade_id_idx ON ledgerEntries (tradeID);
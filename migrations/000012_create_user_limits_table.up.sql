@@ -0,0 +1,10 @@
+CREATE TABLE userLimits (
+    userID INTEGER NOT NULL,
+    symbol VARCHAR NOT NULL DEFAULT '',
+    maxOpenOrders INTEGER NOT NULL DEFAULT 0 CHECK (maxOpenOrders >= 0),
+    maxRestingNotional NUMERIC NOT NULL DEFAULT 0 CHECK (maxRestingNotional >= 0),
+    maxOrderQty NUMERIC NOT NULL DEFAULT 0 CHECK (maxOrderQty >= 0),
+    PRIMARY KEY (userID, symbol)
+);
+
+COMMENT ON TABLE userLimits IS 'userID 0 applies to every user and an empty symbol to every symbol, 0 is no limit';
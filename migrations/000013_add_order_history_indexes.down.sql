@@ -0,0 +1,2 @@
+DROP INDEX orders_user_symbol_created_at_id_idx;
+DROP INDEX orders_user_created_at_id_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX orders_user_created_at_id_idx ON orders (userID, createdAt DESC, id DESC);
+CREATE INDEX orders_user_symbol_created_at_id_idx ON orders (userID, symbol, createdAt DESC, id DESC);